@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/audit"
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/progress"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+var auditLog *audit.Log
+
+// OpenAuditLog starts recording the statements executed by the command into
+// the audit log set with the global --audit-log flag.
+func OpenAuditLog(cmd *cobra.Command, _ []string) error {
+	path, _ := cmd.Flags().GetString("audit-log")
+	if path == "" {
+		return nil
+	}
+
+	// the failures to write the records are reported along with the logs
+	format, _ := cmd.Flags().GetString("log-format")
+	auditLogger, err := logger.NewLogger(progress.Stderr, logger.Options{Format: format})
+	if err != nil {
+		return err
+	}
+
+	auditLog = audit.Open(path)
+	store.SetAuditLog(auditLog, auditLogger)
+
+	return nil
+}
+
+// CloseAuditLog closes the audit log if it's opened.
+func CloseAuditLog() {
+	if auditLog == nil {
+		return
+	}
+
+	store.SetAuditLog(nil, nil)
+	_ = auditLog.Close()
+}
+
+func AuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspects the statements executed against the databases",
+	}
+
+	cmd.AddCommand(AuditShowCmd())
+
+	return cmd
+}
+
+func AuditShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "show",
+		Short:   "Renders the audit log as a report",
+		RunE:    runAuditShowCmdF,
+		Example: "  migration-assist audit show --kind exec --since 24h",
+	}
+
+	cmd.Flags().String("kind", "", "Only shows the statements of the given kind: exec, select or migration")
+	cmd.Flags().Bool("failed", false, "Only shows the failed statements")
+	cmd.Flags().Duration("since", 0, "Only shows the statements executed within the given duration")
+	cmd.Flags().Bool("full", false, "Shows the statements without truncating them")
+
+	return cmd
+}
+
+func runAuditShowCmdF(cmd *cobra.Command, _ []string) error {
+	path, _ := cmd.Flags().GetString("audit-log")
+	kind, _ := cmd.Flags().GetString("kind")
+	failed, _ := cmd.Flags().GetBool("failed")
+	since, _ := cmd.Flags().GetDuration("since")
+	full, _ := cmd.Flags().GetBool("full")
+
+	records, err := audit.Read(path)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tDATABASE\tKIND\tOUTCOME\tROWS\tDURATION\tSTATEMENT")
+
+	var shown, errored int
+	var total time.Duration
+	for _, r := range records {
+		if kind != "" && r.Kind != kind {
+			continue
+		}
+		if failed && r.Outcome != audit.OutcomeError {
+			continue
+		}
+		if since > 0 && time.Since(r.Time) > since {
+			continue
+		}
+
+		statement := strings.Join(strings.Fields(r.Statement), " ")
+		if runes := []rune(statement); !full && len(runes) > 80 {
+			statement = string(runes[:77]) + "..."
+		}
+		if len(r.Params) > 0 {
+			statement += fmt.Sprintf(" %v", r.Params)
+		}
+
+		outcome := r.Outcome
+		if r.Error != "" {
+			outcome += ": " + r.Error
+		}
+
+		rows := "-"
+		if r.Rows >= 0 {
+			rows = fmt.Sprint(r.Rows)
+		}
+
+		fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Local().Format(time.DateTime), r.DBType, r.Database, r.Kind, outcome, rows, r.Duration.Round(time.Millisecond), statement)
+
+		shown++
+		total += r.Duration
+		if r.Outcome == audit.OutcomeError {
+			errored++
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d statement(s), %d failed, %s in total\n", shown, errored, total.Round(time.Millisecond))
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/git"
+)
+
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manages the cached Mattermost migrations",
+	}
+
+	cmd.AddCommand(CacheListCmd())
+	cmd.AddCommand(CachePruneCmd())
+
+	return cmd
+}
+
+func CacheListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the cached migrations",
+		RunE:    runCacheListCmdF,
+		Example: "  migration-assist cache list",
+	}
+
+	return cmd
+}
+
+func CachePruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Removes the cached migrations",
+		RunE:    runCachePruneCmdF,
+		Example: "  migration-assist cache prune --mattermost-version v9.7",
+	}
+
+	cmd.Flags().String("mattermost-version", "", "Only removes the migrations of the given version")
+
+	return cmd
+}
+
+func runCacheListCmdF(_ *cobra.Command, _ []string) error {
+	dir, err := git.CacheDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := git.ListCache()
+	if err != nil {
+		return fmt.Errorf("could not list cache: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "no cached migrations in %s\n", dir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tDRIVER\tFILES\tSIZE\tSTATUS\tPATH")
+	for _, e := range entries {
+		status := "ok"
+		if !e.Valid {
+			status = "corrupted"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d KB\t%s\t%s\n", e.Version, e.Driver, e.Files, e.Size/1024, status, e.Path)
+	}
+
+	return w.Flush()
+}
+
+func runCachePruneCmdF(cmd *cobra.Command, _ []string) error {
+	baseLogger, err := newLogger(cmd)
+	if err != nil {
+		return err
+	}
+	defer baseLogger.Close()
+
+	version, _ := cmd.Flags().GetString("mattermost-version")
+
+	err = git.PruneCache(version)
+	if err != nil {
+		return fmt.Errorf("could not prune cache: %w", err)
+	}
+
+	if version == "" {
+		baseLogger.Info("all cached migrations are removed")
+	} else {
+		baseLogger.Info("cached migrations are removed", "version", version)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/pgloader"
+	"github.com/isacikgoz/migration-assist/internal/progress"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+// jsonBackupRecord is the original value of a JSON column before the fix.
+type jsonBackupRecord struct {
+	Time     time.Time         `json:"time"`
+	Table    string            `json:"table"`
+	Column   string            `json:"column"`
+	Key      map[string]string `json:"key"`
+	Value    string            `json:"value"`
+	Fixed    string            `json:"fixed"`
+	Repaired bool              `json:"repaired"`
+}
+
+// jsonBackup appends the records to a file as JSON lines, the file is opened
+// once the first record is written.
+type jsonBackup struct {
+	path string
+	file *os.File
+}
+
+func (b *jsonBackup) Write(r jsonBackupRecord) error {
+	if b.file == nil {
+		f, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("could not open json backup: %w", err)
+		}
+		b.file = f
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal json backup: %w", err)
+	}
+
+	_, err = b.file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("could not write json backup: %w", err)
+	}
+
+	// the value is updated right after, so it should be on the disk
+	return b.file.Sync()
+}
+
+func (b *jsonBackup) Close() error {
+	if b.file == nil {
+		return nil
+	}
+
+	return b.file.Close()
+}
+
+// getCompatChecks returns the compatibility checks and the JSON checks that
+// are requested. The target Postgres database determines the JSON columns and
+// the integer types that the values are loaded into.
+func getCompatChecks(cmd *cobra.Command, mysqlDB *store.DB, baseLogger *logger.Logger) ([]store.CompatCheck, error) {
+	checkCompat, _ := cmd.Flags().GetBool("check-compat")
+	checkJSON, _ := cmd.Flags().GetBool("check-json")
+	fixJSON, _ := cmd.Flags().GetBool("fix-json")
+	postgresDSN, _ := cmd.Flags().GetString("postgres")
+	schema, _ := cmd.Flags().GetString("schema")
+
+	if !checkCompat && !checkJSON && !fixJSON {
+		return nil, nil
+	}
+
+	var postgresDB *store.DB
+	if postgresDSN != "" {
+		var err error
+		postgresDB, err = store.NewStore("postgres", postgresDSN)
+		if err != nil {
+			return nil, err
+		}
+		defer postgresDB.Close()
+
+		baseLogger.Info("pinging postgres...")
+		err = postgresDB.Ping()
+		if err != nil {
+			return nil, fmt.Errorf("could not ping postgres: %w", err)
+		}
+		baseLogger.Info("connected to postgres successfully")
+	}
+
+	var checks []store.CompatCheck
+	if checkCompat {
+		var target []store.Column
+		if postgresDB != nil {
+			var err error
+			target, err = postgresDB.GetColumns(context.TODO(), schema)
+			if err != nil {
+				return nil, fmt.Errorf("could not read postgres columns: %w", err)
+			}
+		} else {
+			baseLogger.Warn("--postgres is not supplied, the integer ranges are not checked against the target types")
+		}
+
+		compatChecks, err := mysqlDB.GetCompatChecks(context.TODO(), target)
+		if err != nil {
+			return nil, fmt.Errorf("could not get compatibility checks: %w", err)
+		}
+		checks = append(checks, compatChecks...)
+	}
+
+	if checkJSON || fixJSON {
+		if postgresDB == nil {
+			return nil, errors.New("--postgres is required to determine the JSON columns")
+		}
+
+		columns, err := pgloader.JSONColumns(context.TODO(), mysqlDB, postgresDB, schema)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the JSON columns: %w", err)
+		}
+
+		jsonChecks, err := mysqlDB.GetJSONChecks(context.TODO(), columns)
+		if err != nil {
+			return nil, fmt.Errorf("could not get JSON checks: %w", err)
+		}
+		checks = append(checks, jsonChecks...)
+	}
+
+	return checks, nil
+}
+
+// runCompatChecks runs the compatibility checks, and fixes the invalid JSON
+// values if the backup is set.
+func runCompatChecks(db *store.DB, checks []store.CompatCheck, sampleSize int, backup *jsonBackup, baseLogger *logger.Logger, tracker *progress.Tracker) error {
+	baseLogger = baseLogger.With("check_type", "compat")
+	baseLogger.Info("running checks...")
+
+	var findings int
+	for _, check := range checks {
+		baseLogger.Debug("checking...", "check", check.String())
+		tracker.Start("compat/" + check.String())
+		finding, err := db.RunCompatCheck(context.TODO(), check, sampleSize)
+		if err != nil {
+			tracker.Done()
+			return err
+		}
+
+		if finding.Count == 0 {
+			baseLogger.Debug("check is okay", "check", check.String())
+			tracker.Done()
+			continue
+		}
+		findings++
+
+		baseLogger.Warn("incompatible values found", "check", check.Kind, "table", check.Table, "column", check.Column, "count", finding.Count, "sample_keys", strings.Join(finding.SampleKeys, "; "))
+		if check.Kind != store.CompatInvalidJSON || backup == nil {
+			tracker.Done()
+			continue
+		}
+
+		err = fixInvalidJSON(db, check, backup, baseLogger)
+		tracker.Done()
+		if err != nil {
+			return fmt.Errorf("error while trying to fix %s: %w", check.String(), err)
+		}
+		findings--
+	}
+
+	if findings == 0 {
+		baseLogger.Info(fmt.Sprintf("%d checks been made, all good", len(checks)))
+	} else {
+		baseLogger.Warn(fmt.Sprintf("%d checks been made, %d column(s) have incompatible values", len(checks), findings))
+	}
+
+	return nil
+}
+
+// fixInvalidJSON repairs or resets the invalid JSON values of the column, the
+// original values are written to the backup before they are updated.
+func fixInvalidJSON(db *store.DB, check store.CompatCheck, backup *jsonBackup, baseLogger *logger.Logger) error {
+	rows, err := db.GetInvalidJSONRows(context.TODO(), check)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	empty, err := db.GetEmptyJSONValue(context.TODO(), check)
+	if err != nil {
+		return err
+	}
+
+	var repaired int
+	for _, row := range rows {
+		fixed, ok := store.RepairJSON(row.Value, empty)
+
+		key := make(map[string]string, len(row.Key))
+		for i, k := range check.PrimaryKey() {
+			key[k] = row.Key[i]
+		}
+
+		err = backup.Write(jsonBackupRecord{
+			Time:     time.Now(),
+			Table:    check.Table,
+			Column:   check.Column,
+			Key:      key,
+			Value:    row.Value,
+			Fixed:    fixed,
+			Repaired: ok,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = db.UpdateJSONValue(context.TODO(), check, row.Key, fixed)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			repaired++
+		}
+	}
+
+	baseLogger.Info("the invalid JSON values have been fixed", "check", check.String(), "repaired", repaired, "reset", len(rows)-repaired, "backup", backup.path)
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/store"
+	"github.com/isacikgoz/migration-assist/queries"
+)
+
+const (
+	// postgresTupleOverhead is the size of the tuple header and the line
+	// pointer of a Postgres row.
+	postgresTupleOverhead = 28
+	// fullTextIndexRatio is the rough size of a full-text search index
+	// relative to the data of its table.
+	fullTextIndexRatio = 0.5
+)
+
+var postMigrateTableRegex = regexp.MustCompile(`ON \{\{ \.Schema \}\}\.(\w+)`)
+
+type tableEstimate struct {
+	Table        string
+	Rows         int64
+	MySQLSize    int64
+	PostgresSize int64
+	Load         time.Duration
+	IndexBuild   time.Duration
+}
+
+// loadRates are the throughputs that the estimates are computed with.
+type loadRates struct {
+	RowsPerSecond    float64
+	MBPerSecond      float64
+	IndexMBPerSecond float64
+}
+
+func EstimateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "estimate",
+		Short:   "Estimates the duration of the migration and the size of the Postgres database",
+		RunE:    runEstimateCmdF,
+		Example: "  migration-assist estimate \"root:mostest@tcp(localhost:3306)/mattermost_test\" \\\n--benchmark",
+		Args:    cobra.MinimumNArgs(1),
+	}
+
+	cmd.Flags().Float64("rows-per-second", 20_000, "Rows loaded by pgloader in a second")
+	cmd.Flags().Float64("mb-per-second", 20, "Megabytes loaded by pgloader in a second")
+	cmd.Flags().Float64("index-mb-per-second", 10, "Megabytes of table data indexed in a second while building the full-text search indexes")
+	cmd.Flags().Int("parallel", 1, "Number of pgloader configurations to be loaded at the same time if the load is split")
+	cmd.Flags().Bool("benchmark", false, "Measures the MySQL read throughput from the largest table, which caps --rows-per-second and --mb-per-second")
+	cmd.Flags().Int("benchmark-rows", 100_000, "Number of rows to be read for the benchmark")
+
+	return cmd
+}
+
+func runEstimateCmdF(cmd *cobra.Command, args []string) error {
+	rowsPerSecond, _ := cmd.Flags().GetFloat64("rows-per-second")
+	mbPerSecond, _ := cmd.Flags().GetFloat64("mb-per-second")
+	indexMBPerSecond, _ := cmd.Flags().GetFloat64("index-mb-per-second")
+	parallel, _ := cmd.Flags().GetInt("parallel")
+	benchmark, _ := cmd.Flags().GetBool("benchmark")
+	benchmarkRows, _ := cmd.Flags().GetInt("benchmark-rows")
+	baseLogger, err := newLogger(cmd)
+	if err != nil {
+		return err
+	}
+	defer baseLogger.Close()
+
+	mysqlDB, err := store.NewStore("mysql", args[0])
+	if err != nil {
+		return err
+	}
+	defer mysqlDB.Close()
+
+	baseLogger.Info("pinging mysql...")
+	err = mysqlDB.Ping()
+	if err != nil {
+		return fmt.Errorf("could not ping mysql: %w", err)
+	}
+	baseLogger.Info("connected to mysql successfully")
+
+	stats, err := mysqlDB.GetTableStats(context.TODO())
+	if err != nil {
+		return fmt.Errorf("could not get table stats: %w", err)
+	}
+
+	if len(stats) == 0 {
+		return fmt.Errorf("there are no tables in the database")
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].DataLength+stats[i].IndexLength > stats[j].DataLength+stats[j].IndexLength
+	})
+
+	if benchmark {
+		largest := stats[0]
+		for _, s := range stats {
+			if s.Rows > largest.Rows {
+				largest = s
+			}
+		}
+
+		baseLogger.Info("benchmarking...", "table", largest.Name, "rows", benchmarkRows)
+		t, err2 := mysqlDB.BenchmarkRead(context.TODO(), largest.Name, benchmarkRows)
+		if err2 != nil {
+			return fmt.Errorf("could not benchmark: %w", err2)
+		}
+
+		if t.Rows == 0 {
+			return fmt.Errorf("could not benchmark, %s is empty", largest.Name)
+		}
+
+		// the benchmark only measures the reads, pgloader is usually bound by
+		// the writes into Postgres, hence the reads only cap the load rates
+		readRows := t.RowsPerSecond()
+		readMB := t.BytesPerSecond() / (1 << 20)
+		baseLogger.Info("benchmark is completed, the mysql read throughput caps the load rates", "read_rows_per_second", int64(readRows), "read_mb_per_second", fmt.Sprintf("%.1f", readMB))
+
+		rowsPerSecond = min(rowsPerSecond, readRows)
+		mbPerSecond = min(mbPerSecond, readMB)
+	}
+
+	if rowsPerSecond <= 0 || mbPerSecond <= 0 || indexMBPerSecond <= 0 {
+		return fmt.Errorf("the throughput should be positive")
+	}
+
+	indexed, err := postMigrateTables()
+	if err != nil {
+		return err
+	}
+
+	rates := loadRates{
+		RowsPerSecond:    rowsPerSecond,
+		MBPerSecond:      mbPerSecond,
+		IndexMBPerSecond: indexMBPerSecond,
+	}
+
+	estimates := make([]tableEstimate, 0, len(stats))
+	for _, s := range stats {
+		estimates = append(estimates, estimateTable(s, len(indexed[strings.ToLower(s.Name)]), rates))
+	}
+	total := totalEstimate(estimates, parallel)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS\tMYSQL SIZE\tPOSTGRES SIZE\tLOAD\tINDEX BUILD")
+	for _, e := range estimates {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", e.Table, e.Rows, formatBytes(e.MySQLSize), formatBytes(e.PostgresSize), formatEstimate(e.Load), formatEstimate(e.IndexBuild))
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", "TOTAL", total.Rows, formatBytes(total.MySQLSize), formatBytes(total.PostgresSize), formatEstimate(total.Load), formatEstimate(total.IndexBuild))
+	w.Flush()
+
+	baseLogger.Info("estimated the migration", "load", formatEstimate(total.Load), "index_build", formatEstimate(total.IndexBuild), "total", formatEstimate(total.Load+total.IndexBuild), "postgres_size", formatBytes(total.PostgresSize))
+
+	return nil
+}
+
+// estimateTable estimates the load of the table and the build of its
+// full-text search indexes.
+func estimateTable(s store.TableStat, indexes int, rates loadRates) tableEstimate {
+	e := tableEstimate{
+		Table:     s.Name,
+		Rows:      s.Rows,
+		MySQLSize: s.DataLength + s.IndexLength,
+	}
+
+	// the load is bound by either the number of rows or their size
+	byRows := float64(s.Rows) / rates.RowsPerSecond
+	bySize := float64(s.DataLength) / (rates.MBPerSecond * (1 << 20))
+	e.Load = time.Duration(max(byRows, bySize) * float64(time.Second))
+
+	e.PostgresSize = s.DataLength + s.Rows*postgresTupleOverhead + s.IndexLength
+	for range indexes {
+		e.PostgresSize += int64(float64(s.DataLength) * fullTextIndexRatio)
+		e.IndexBuild += time.Duration(float64(s.DataLength) / (rates.IndexMBPerSecond * (1 << 20)) * float64(time.Second))
+	}
+
+	return e
+}
+
+// totalEstimate sums the estimates of the tables. The split loads run in
+// parallel, but not faster than the load of the largest table.
+func totalEstimate(estimates []tableEstimate, parallel int) tableEstimate {
+	var total tableEstimate
+	var longestLoad time.Duration
+	for _, e := range estimates {
+		total.Rows += e.Rows
+		total.MySQLSize += e.MySQLSize
+		total.PostgresSize += e.PostgresSize
+		total.Load += e.Load
+		total.IndexBuild += e.IndexBuild
+		longestLoad = max(longestLoad, e.Load)
+	}
+
+	if parallel > 1 {
+		total.Load = max(total.Load/time.Duration(parallel), longestLoad)
+	}
+
+	return total
+}
+
+// postMigrateTables returns the post-migrate queries that build full-text
+// search indexes on each table.
+func postMigrateTables() (map[string][]string, error) {
+	assets := queries.Assets()
+
+	entries, err := assets.ReadDir("post-migrate")
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string][]string)
+	for _, entry := range entries {
+		b, err := assets.ReadFile(path.Join("post-migrate", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read embedded sql file: %w", err)
+		}
+
+		for _, m := range postMigrateTableRegex.FindAllStringSubmatch(string(b), -1) {
+			table := strings.ToLower(m[1])
+			tables[table] = append(tables[table], entry.Name())
+		}
+	}
+
+	return tables, nil
+}
+
+func formatEstimate(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+
+	return d.Round(time.Second).String()
+}
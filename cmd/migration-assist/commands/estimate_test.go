@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+const mb = 1 << 20
+
+func TestEstimateTable(t *testing.T) {
+	rates := loadRates{RowsPerSecond: 20_000, MBPerSecond: 20, IndexMBPerSecond: 10}
+
+	tests := []struct {
+		name     string
+		stat     store.TableStat
+		indexes  int
+		expected tableEstimate
+	}{
+		{
+			name: "bound by rows",
+			stat: store.TableStat{Name: "Reactions", Rows: 40_000, DataLength: 10 * mb, IndexLength: mb},
+			expected: tableEstimate{
+				Table:        "Reactions",
+				Rows:         40_000,
+				MySQLSize:    11 * mb,
+				PostgresSize: 11*mb + 40_000*postgresTupleOverhead,
+				Load:         2 * time.Second,
+			},
+		},
+		{
+			name: "bound by size",
+			stat: store.TableStat{Name: "FileInfo", Rows: 1_000, DataLength: 60 * mb},
+			expected: tableEstimate{
+				Table:        "FileInfo",
+				Rows:         1_000,
+				MySQLSize:    60 * mb,
+				PostgresSize: 60*mb + 1_000*postgresTupleOverhead,
+				Load:         3 * time.Second,
+			},
+		},
+		{
+			name:    "full-text search indexes",
+			stat:    store.TableStat{Name: "Posts", Rows: 20_000, DataLength: 20 * mb, IndexLength: 2 * mb},
+			indexes: 2,
+			expected: tableEstimate{
+				Table:        "Posts",
+				Rows:         20_000,
+				MySQLSize:    22 * mb,
+				PostgresSize: 42*mb + 20_000*postgresTupleOverhead,
+				Load:         time.Second,
+				IndexBuild:   4 * time.Second,
+			},
+		},
+		{
+			name:     "empty table",
+			stat:     store.TableStat{Name: "Jobs"},
+			expected: tableEstimate{Table: "Jobs"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := estimateTable(tc.stat, tc.indexes, rates); got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTotalEstimate(t *testing.T) {
+	estimates := []tableEstimate{
+		{Table: "Posts", Rows: 10, MySQLSize: 100, PostgresSize: 150, Load: 4 * time.Minute, IndexBuild: time.Minute},
+		{Table: "Users", Rows: 5, MySQLSize: 50, PostgresSize: 70, Load: 3 * time.Minute},
+		{Table: "Channels", Rows: 1, MySQLSize: 10, PostgresSize: 20, Load: 3 * time.Minute},
+	}
+
+	tests := []struct {
+		name     string
+		parallel int
+		load     time.Duration
+	}{
+		{name: "sequential", parallel: 1, load: 10 * time.Minute},
+		{name: "parallel", parallel: 2, load: 5 * time.Minute},
+		{name: "bound by the largest table", parallel: 4, load: 4 * time.Minute},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := tableEstimate{Rows: 16, MySQLSize: 160, PostgresSize: 240, Load: tc.load, IndexBuild: time.Minute}
+			if got := totalEstimate(estimates, tc.parallel); got != expected {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+		})
+	}
+}
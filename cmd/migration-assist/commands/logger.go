@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/progress"
+)
+
+// newTracker tracks the progress of the tasks. The progress is rendered as a
+// live line if stderr is a terminal and the logs are in text, otherwise it's
+// logged periodically.
+func newTracker(cmd *cobra.Command, tasks []progress.Task, baseLogger *logger.Logger) *progress.Tracker {
+	format, _ := cmd.Flags().GetString("log-format")
+	interval, _ := cmd.Flags().GetDuration("progress-interval")
+
+	opts := progress.Options{Interval: interval}
+	if format != logger.FormatJSON && progress.IsTerminal(os.Stderr) {
+		opts.Console = progress.Stderr
+	}
+
+	return progress.NewTracker(tasks, baseLogger, opts)
+}
+
+// newLogger creates the logger with the global logging flags, it should be
+// closed to release the log file.
+func newLogger(cmd *cobra.Command) (*logger.Logger, error) {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	format, _ := cmd.Flags().GetString("log-format")
+	file, _ := cmd.Flags().GetString("log-file")
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	return logger.NewLogger(progress.Stderr, logger.Options{
+		Format: format,
+		Level:  level,
+		File:   file,
+	})
+}
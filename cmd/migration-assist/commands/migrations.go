@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/blang/semver/v4"
+	"github.com/spf13/cobra"
+
+	"github.com/isacikgoz/migration-assist/internal/git"
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/store"
+	"github.com/isacikgoz/migration-assist/migrations"
+)
+
+func addMigrationSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().String("migrations-source", "git", "Where to fetch the migrations from: git, github-api, tarball or local")
+	cmd.Flags().String("git", "git", "git binary to be executed if the repository will be cloned")
+	cmd.Flags().String("mattermost-repo", "", "Path of a local checkout of the Mattermost repository (used with --migrations-source=local)")
+}
+
+func migrationSourceFromFlags(cmd *cobra.Command) (git.MigrationSource, error) {
+	kind, _ := cmd.Flags().GetString("migrations-source")
+	binary, _ := cmd.Flags().GetString("git")
+	repo, _ := cmd.Flags().GetString("mattermost-repo")
+
+	return git.NewMigrationSource(kind, binary, repo)
+}
+
+// resolveMigrations returns the migrations of the driver for the version. The
+// migrations directory takes precedence if it's set, then the migrations
+// embedded into the binary are used. Otherwise the migrations are fetched
+// from the migrations source into a directory named after the driver.
+func resolveMigrations(cmd *cobra.Command, driver string, v semver.Version, baseLogger *logger.Logger) (fs.FS, error) {
+	migrationsDir, _ := cmd.Flags().GetString("migrations-dir")
+	if migrationsDir != "" {
+		return os.DirFS(migrationsDir), nil
+	}
+
+	if assets, embedded, ok := migrations.FS(driver, v); ok {
+		baseLogger.Info("using embedded migrations", "version", v.String(), "embedded_version", embedded.String())
+		return assets, nil
+	}
+
+	src, err := migrationSourceFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	baseLogger.Info("fetching migrations", "version", v.String(), "driver", driver)
+	err = git.CloneMigrations(git.CloneOptions{
+		Output:     driver,
+		DriverType: driver,
+		Version:    v,
+		Source:     src,
+	}, baseLogger)
+	if err != nil {
+		return nil, fmt.Errorf("error during cloning migrations: %w", err)
+	}
+
+	return os.DirFS(driver), nil
+}
+
+// mattermostVersion returns the Mattermost version whose migrations should be
+// used. If the source database is given, the version is detected from it and
+// the version supplied with --mattermost-version must match with it.
+func mattermostVersion(cmd *cobra.Command, source *store.DB, baseLogger *logger.Logger) (semver.Version, error) {
+	mmVersion, _ := cmd.Flags().GetString("mattermost-version")
+
+	var installed *store.InstalledVersion
+	if source != nil {
+		var err error
+		installed, err = source.DetectMattermostVersion(context.TODO())
+		if err != nil {
+			if mmVersion == "" {
+				return semver.Version{}, fmt.Errorf("could not detect the Mattermost version, consider setting --mattermost-version: %w", err)
+			}
+			baseLogger.Warn("could not detect the Mattermost version", "err", err)
+		}
+	}
+
+	if installed != nil {
+		if installed.LastMigration != nil {
+			baseLogger.Info("detected Mattermost version", "version", installed.Version.String(), "last_migration", installed.LastMigration.Version, "last_migration_name", installed.LastMigration.Name)
+		} else {
+			baseLogger.Info("detected Mattermost version", "version", installed.Version.String())
+		}
+	}
+
+	if mmVersion == "" {
+		if installed == nil {
+			return semver.Version{}, fmt.Errorf("--mattermost-version is required if the version can't be detected from the source database")
+		}
+		return installed.Version, nil
+	}
+
+	v, err := semver.ParseTolerant(mmVersion)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("could not parse version: %w", err)
+	}
+
+	if installed != nil && (v.Major != installed.Version.Major || v.Minor != installed.Version.Minor) {
+		return semver.Version{}, fmt.Errorf("the supplied version v%s conflicts with the installed version v%s", v.String(), installed.Version.String())
+	}
+
+	return v, nil
+}
@@ -3,10 +3,12 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"log"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/spf13/cobra"
@@ -14,8 +16,8 @@ import (
 
 	module "github.com/testcontainers/testcontainers-go/modules/mysql"
 
-	"github.com/isacikgoz/migration-assist/internal/git"
 	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/progress"
 	"github.com/isacikgoz/migration-assist/internal/store"
 	"github.com/isacikgoz/migration-assist/queries"
 )
@@ -33,24 +35,31 @@ func SourceCheckCmd() *cobra.Command {
 	cmd.Flags().Bool("fix-artifacts", false, "Removes the artifacts from older versions of Mattermost")
 	cmd.Flags().Bool("fix-varchar", false, "Removes the rows with varchar overflow")
 	cmd.Flags().Bool("fix-unicode", false, "Removes the unsupported unicode characters from MySQL tables")
+	cmd.Flags().Bool("check-orphans", false, "Counts the rows that reference missing rows of other tables, it may take a long time on large databases")
+	cmd.Flags().Bool("fix-orphans", false, "Moves the rows that reference missing rows of other tables into orphaned_<table> tables")
 	cmd.Flags().Bool("full-schema-check", false, "Checks the MySQL schema to determine whether it's in desired state")
 	cmd.Flags().Bool("save-diff", false, "Writes diffs to files")
+	cmd.Flags().Bool("check-compat", false, "Scans the tables for the values that pgloader or Postgres would reject or change, it may take a long time on large databases")
+	cmd.Flags().Int("sample-size", 5, "Number of sample keys to be reported for each compatibility finding")
+	cmd.Flags().Bool("check-json", false, "Validates the values of the columns that are loaded as JSON, requires --postgres")
+	cmd.Flags().Bool("fix-json", false, "Repairs the invalid JSON values or resets them to {} or [], the original values are backed up to --json-backup")
+	cmd.Flags().String("json-backup", "migration-assist-json-backup.jsonl", "The file that the original values of the fixed JSON columns are appended to")
+	cmd.Flags().String("postgres", "", "DSN for the target Postgres database to determine the JSON columns and the integer ranges")
+	cmd.Flags().String("schema", "public", "The Postgres schema that the data will be loaded into")
+	cmd.Flags().Duration("progress-interval", time.Minute, "Interval to log the progress of the checks if stderr is not a terminal, 0 disables it")
 	cmd.Flags().String("migrations-dir", "", "Migrations directory (should be used if mattermost-version is not supplied)")
-	cmd.Flags().String("mattermost-version", "v9.7", "Mattermost version to be cloned to run migrations")
+	cmd.Flags().String("mattermost-version", "", "Mattermost version to be cloned to run migrations (detected from the database if not supplied)")
+	addMigrationSourceFlags(cmd)
 
 	return cmd
 }
 
 func runSourceCheckCmdF(cmd *cobra.Command, args []string) error {
-	baseLogger := logger.NewLogger(os.Stderr, logger.Options{Timestamps: true})
-	var verboseLogger logger.LogInterface
-
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	if verbose {
-		verboseLogger = baseLogger
-	} else {
-		verboseLogger = logger.NewNopLogger()
+	baseLogger, err := newLogger(cmd)
+	if err != nil {
+		return err
 	}
+	defer baseLogger.Close()
 
 	mysqlDB, err := store.NewStore("mysql", args[0])
 	if err != nil {
@@ -58,30 +67,32 @@ func runSourceCheckCmdF(cmd *cobra.Command, args []string) error {
 	}
 	defer mysqlDB.Close()
 
-	baseLogger.Println("pinging mysql...")
+	baseLogger.Info("pinging mysql...")
 	err = mysqlDB.Ping()
 	if err != nil {
 		return fmt.Errorf("could not ping mysql: %w", err)
 	}
-	baseLogger.Println("connected to mysql successfully...")
+	baseLogger.Info("connected to mysql successfully")
 
 	fullSchema, _ := cmd.Flags().GetBool("full-schema-check")
 	if fullSchema {
-		mmVersion, _ := cmd.Flags().GetString("mattermost-version")
-		v, err2 := semver.ParseTolerant(mmVersion)
-		if err2 != nil {
-			return fmt.Errorf("could not parse version: %w", err2)
+		var v semver.Version
+		if migrationsDir, _ := cmd.Flags().GetString("migrations-dir"); migrationsDir == "" {
+			var err2 error
+			v, err2 = mattermostVersion(cmd, mysqlDB, baseLogger)
+			if err2 != nil {
+				return err2
+			}
 		}
 
-		tempDir, err3 := os.MkdirTemp("", "mattermost")
+		migrations, err3 := resolveMigrations(cmd, "mysql", v, baseLogger)
 		if err3 != nil {
-			return fmt.Errorf("could not create temp directory: %w", err3)
+			return err3
 		}
 
-		migrationsDir, _ := cmd.Flags().GetString("migrations-dir")
 		saveDiff, _ := cmd.Flags().GetBool("save-diff")
 
-		err = runFullSchemaCheck(mysqlDB, migrationsDir, tempDir, v, baseLogger, verboseLogger, saveDiff)
+		err = runFullSchemaCheck(mysqlDB, migrations, baseLogger, saveDiff)
 		if err != nil {
 			return fmt.Errorf("error during full schema check: %w", err)
 		}
@@ -96,35 +107,141 @@ func runSourceCheckCmdF(cmd *cobra.Command, args []string) error {
 
 	// run MySQL schema checks
 	fixArtifacts, _ := cmd.Flags().GetBool("fix-artifacts")
+	fixUnicode, _ := cmd.Flags().GetBool("fix-unicode")
+	fixVarchar, _ := cmd.Flags().GetBool("fix-varchar")
+	checkOrphans, _ := cmd.Flags().GetBool("check-orphans")
+	fixOrphans, _ := cmd.Flags().GetBool("fix-orphans")
+
+	type checkType struct {
+		name string
+		fix  bool
+		// repeat runs the checks again until no fixes are applied
+		repeat bool
+	}
 
-	err = runChecksForMySQL(mysqlDB, "artifacts", fixArtifacts, baseLogger, verboseLogger)
-	if err != nil {
-		return fmt.Errorf("error during running artifact checks for mysql: %w", err)
+	checkTypes := []checkType{
+		{name: "artifacts", fix: fixArtifacts},
+		{name: "unicode", fix: fixUnicode},
+		{name: "varchar", fix: fixVarchar},
+		{name: "varchar-extended", fix: fixVarchar},
+	}
+	if checkOrphans || fixOrphans {
+		// deleting the orphans may orphan the rows of other tables, e.g. the
+		// file infos of the deleted posts
+		checkTypes = append(checkTypes, checkType{name: "orphans", fix: fixOrphans, repeat: true})
 	}
 
-	fixUnicode, _ := cmd.Flags().GetBool("fix-unicode")
+	names := make([]string, 0, len(checkTypes))
+	for _, ct := range checkTypes {
+		names = append(names, ct.name)
+	}
 
-	err = runChecksForMySQL(mysqlDB, "unicode", fixUnicode, baseLogger, verboseLogger)
+	fixJSON, _ := cmd.Flags().GetBool("fix-json")
+	compatChecks, err := getCompatChecks(cmd, mysqlDB, baseLogger)
 	if err != nil {
-		return fmt.Errorf("error during running unicode checks for mysql: %w", err)
+		return err
 	}
 
-	fixVarchar, _ := cmd.Flags().GetBool("fix-varchar")
-
-	err = runChecksForMySQL(mysqlDB, "varchar", fixVarchar, baseLogger, verboseLogger)
+	tasks, err := checkTasks(mysqlDB, names, compatChecks)
 	if err != nil {
-		return fmt.Errorf("error during running varchar checks for mysql: %w", err)
+		return err
 	}
 
-	err = runChecksForMySQL(mysqlDB, "varchar-extended", fixVarchar, baseLogger, verboseLogger)
-	if err != nil {
-		return fmt.Errorf("error during running varchar checks for mysql: %w", err)
+	tracker := newTracker(cmd, tasks, baseLogger)
+	defer tracker.Stop()
+
+	for _, ct := range checkTypes {
+		err = runChecksForMySQL(mysqlDB, ct.name, ct.fix, ct.repeat, baseLogger, tracker)
+		if err != nil {
+			return fmt.Errorf("error during running %s checks for mysql: %w", ct.name, err)
+		}
+	}
+
+	if len(compatChecks) > 0 {
+		sampleSize, _ := cmd.Flags().GetInt("sample-size")
+		backupPath, _ := cmd.Flags().GetString("json-backup")
+
+		var backup *jsonBackup
+		if fixJSON {
+			backup = &jsonBackup{path: backupPath}
+			defer backup.Close()
+		}
+
+		err = runCompatChecks(mysqlDB, compatChecks, sampleSize, backup, baseLogger, tracker)
+		if err != nil {
+			return fmt.Errorf("error during running compatibility checks for mysql: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func createProcedures(db *store.DB, baseLogger logger.LogInterface) (func(), error) {
+// checkTasks returns the checks of the given types and the compatibility
+// checks weighted by the estimated row counts of the tables that they check.
+func checkTasks(db *store.DB, checkTypes []string, compatChecks []store.CompatCheck) ([]progress.Task, error) {
+	stats, err := db.GetTableStats(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("could not get table stats: %w", err)
+	}
+
+	rows := make(map[string]int64, len(stats))
+	for _, s := range stats {
+		rows[strings.ToLower(s.Name)] = s.Rows
+	}
+
+	assets := queries.Assets()
+
+	var tasks []progress.Task
+	for _, checkType := range checkTypes {
+		checks, err := assets.ReadDir(filepath.Join("checks", checkType))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, check := range checks {
+			if !strings.HasPrefix(check.Name(), "check") {
+				continue
+			}
+
+			name := stripQueryName(check.Name())
+
+			tasks = append(tasks, progress.Task{
+				Name:   checkType + "/" + name,
+				Weight: rows[checkTable(name, rows)],
+			})
+		}
+	}
+
+	for _, check := range compatChecks {
+		tasks = append(tasks, progress.Task{
+			Name:   "compat/" + check.String(),
+			Weight: rows[strings.ToLower(check.Table)],
+		})
+	}
+
+	return tasks, nil
+}
+
+// checkTable returns the table of a check that is named after the table and
+// the column, e.g. posts.props or users_props. The longest prefix that is a
+// known table is used, since the table names may contain underscores too,
+// e.g. schema_migrations.
+func checkTable(name string, tables map[string]int64) string {
+	table, _, _ := strings.Cut(name, ".")
+	for t := table; ; {
+		if _, ok := tables[t]; ok {
+			return t
+		}
+
+		i := strings.LastIndex(t, "_")
+		if i < 0 {
+			return table
+		}
+		t = t[:i]
+	}
+}
+
+func createProcedures(db *store.DB, baseLogger *logger.Logger) (func(), error) {
 	assets := queries.Assets()
 
 	procedures, err := assets.ReadDir("procedures")
@@ -138,11 +255,11 @@ func createProcedures(db *store.DB, baseLogger logger.LogInterface) (func(), err
 		}
 		b, err := assets.ReadFile(filepath.Join("procedures", procedure.Name()))
 		if err != nil {
-			baseLogger.Printf("could not read embedded sql file: %s", err)
+			baseLogger.Error("could not read embedded sql file", "procedure", procedure.Name(), "err", err)
 		}
 		err = db.ExecQuery(context.TODO(), string(b))
 		if err != nil {
-			baseLogger.Printf("error during creating procedures: %s", err)
+			baseLogger.Error("error during creating procedures", "procedure", procedure.Name(), "err", err)
 		}
 	}
 
@@ -153,11 +270,11 @@ func createProcedures(db *store.DB, baseLogger logger.LogInterface) (func(), err
 			}
 			b, err := assets.ReadFile(filepath.Join("procedures", procedure.Name()))
 			if err != nil {
-				baseLogger.Printf("could not read embedded sql file: %s", err)
+				baseLogger.Error("could not read embedded sql file", "procedure", procedure.Name(), "err", err)
 			}
 			err = db.ExecQuery(context.TODO(), string(b))
 			if err != nil {
-				baseLogger.Printf("error during dropping procedures: %s", err)
+				baseLogger.Error("error during dropping procedures", "procedure", procedure.Name(), "err", err)
 			}
 		}
 	}
@@ -165,16 +282,33 @@ func createProcedures(db *store.DB, baseLogger logger.LogInterface) (func(), err
 	return cleanUpFn, nil
 }
 
-func runChecksForMySQL(db *store.DB, checkType string, fix bool, baseLogger, verboseLogger logger.LogInterface) error {
+// runChecksForMySQL runs the checks of the type and the fixes of the failing
+// ones if fix is set. If repeat is set, the checks are run again until a pass
+// applies no fixes, the repeated passes are not tracked.
+func runChecksForMySQL(db *store.DB, checkType string, fix, repeat bool, baseLogger *logger.Logger, tracker *progress.Tracker) error {
+	baseLogger = baseLogger.With("check_type", checkType)
+	baseLogger.Info("running checks...")
+
+	fixed, err := runCheckPass(db, checkType, fix, baseLogger, tracker)
+	for repeat && fixed > 0 && err == nil {
+		baseLogger.Info("running checks again, the fixes may have left other rows to be fixed", "fixed", fixed)
+		fixed, err = runCheckPass(db, checkType, fix, baseLogger, nil)
+	}
+
+	return err
+}
+
+// runCheckPass runs the checks of the type once and returns the number of
+// fixes applied.
+func runCheckPass(db *store.DB, checkType string, fix bool, baseLogger *logger.Logger, tracker *progress.Tracker) (int, error) {
 	assets := queries.Assets()
 
 	checks, err := assets.ReadDir(filepath.Join("checks", checkType))
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	var fixRequired, totalCheck int
-	baseLogger.Printf("running checks for %s...\n", checkType)
+	var fixRequired, fixed, totalCheck int
 	for _, artifact := range checks {
 		if !strings.HasPrefix(artifact.Name(), "check") {
 			continue
@@ -182,45 +316,55 @@ func runChecksForMySQL(db *store.DB, checkType string, fix bool, baseLogger, ver
 		name := stripQueryName(artifact.Name())
 		b, err := assets.ReadFile(filepath.Join("checks", checkType, artifact.Name()))
 		if err != nil {
-			return fmt.Errorf("could not read embedded sql file: %w", err)
+			return fixed, fmt.Errorf("could not read embedded sql file: %w", err)
 		}
-		verboseLogger.Printf("checking %s...", name)
+		baseLogger.Debug("checking...", "check", name)
+		tracker.Start(checkType + "/" + name)
 		count, err := db.RunSelectCountQuery(context.TODO(), string(b))
 		if err != nil {
-			return fmt.Errorf("error during running checks: %w", err)
+			return fixed, fmt.Errorf("error during running checks: %w", err)
 		}
 		totalCheck++
 		if count == 0 {
-			verboseLogger.Printf("%s is okay", name)
+			baseLogger.Debug("check is okay", "check", name)
+			tracker.Done()
 			continue
 		}
 		fixRequired++
 
-		baseLogger.Printf("a fix is required for: %s\n", name)
+		baseLogger.Warn("a fix is required", "check", name, "count", count)
 		if !fix {
+			tracker.Done()
 			continue
 		}
 
 		fixQ, err := assets.ReadFile(filepath.Join("fixes", checkType, "fix_"+strings.TrimPrefix(artifact.Name(), "check_")))
 		if err != nil {
-			return fmt.Errorf("could not read embedded sql file: %w", err)
+			return fixed, fmt.Errorf("could not read embedded sql file: %w", err)
 		}
 
 		err = db.ExecQuery(context.TODO(), string(fixQ))
 		if err != nil {
-			return fmt.Errorf("error while trying to fix %s error: %w", name, err)
+			// the statements after the failed one are not executed, hence
+			// the transaction of the fix is left open on the connection
+			if err2 := db.ExecQuery(context.TODO(), "ROLLBACK"); err2 != nil {
+				baseLogger.Warn("could not roll back the fix", "check", name, "err", err2)
+			}
+			return fixed, fmt.Errorf("error while trying to fix %s error: %w", name, err)
 		}
-		baseLogger.Println("the fix query has been executed successfully.")
+		baseLogger.Info("the fix query has been executed successfully", "check", name)
+		tracker.Done()
 		fixRequired--
+		fixed++
 	}
 
 	if fixRequired == 0 {
-		baseLogger.Printf("%d checks been made, all good for %s\n", totalCheck, checkType)
+		baseLogger.Info(fmt.Sprintf("%d checks been made, all good", totalCheck))
 	} else {
-		baseLogger.Printf("%d checks been made, %d fix(es) is required for %s\n", totalCheck, fixRequired, checkType)
+		baseLogger.Warn(fmt.Sprintf("%d checks been made, %d fix(es) is required", totalCheck, fixRequired))
 	}
 
-	return nil
+	return fixed, nil
 }
 
 func stripQueryName(fileName string) string {
@@ -229,17 +373,17 @@ func stripQueryName(fileName string) string {
 	return strings.TrimSuffix(fileName, ".sql")
 }
 
-func runFullSchemaCheck(db *store.DB, migrationsDir, tempDir string, v semver.Version, baseLogger, verboseLogger logger.LogInterface, saveDiff bool) error {
+func runFullSchemaCheck(db *store.DB, migrations fs.FS, baseLogger *logger.Logger, saveDiff bool) error {
 	ctx := context.Background()
 
 	var mysqlContainer *module.MySQLContainer
 	var err error
 
-	baseLogger.Println("setting up a test MySQL instance...")
+	baseLogger.Info("setting up a test MySQL instance...")
 	mysqlContainer, err = module.RunContainer(ctx,
 		// TODO: get version from user database
 		testcontainers.WithImage("mysql:8.0.36"),
-		testcontainers.WithLogger(verboseLogger),
+		testcontainers.WithLogger(baseLogger.Printer(slog.LevelDebug)),
 		module.WithDatabase("foo"),
 		module.WithDefaultCredentials(),
 	)
@@ -247,7 +391,7 @@ func runFullSchemaCheck(db *store.DB, migrationsDir, tempDir string, v semver.Ve
 		log.Fatalf("failed to start container: %s", err)
 	}
 	defer func() {
-		verboseLogger.Println("terminating test container...")
+		baseLogger.Debug("terminating test container...")
 
 		if err2 := mysqlContainer.Terminate(ctx); err2 != nil {
 			log.Fatalf("failed to terminate container: %s", err2)
@@ -259,22 +403,6 @@ func runFullSchemaCheck(db *store.DB, migrationsDir, tempDir string, v semver.Ve
 		log.Fatalf("failed to get connection string of container: %s", err)
 	}
 
-	dir := "mysql"
-	if migrationsDir == "" {
-		baseLogger.Printf("cloning %s@%s\n", "repository", v.String())
-		err = git.CloneMigrations(git.CloneOptions{
-			TempRepoPath: tempDir,
-			Output:       dir,
-			DriverType:   "mysql",
-			Version:      v,
-		}, verboseLogger)
-		if err != nil {
-			return fmt.Errorf("error during cloning migrations: %w", err)
-		}
-	} else {
-		dir = migrationsDir
-	}
-
 	// create mysql connection
 	testDB, err := store.NewStore("mysql", connectionString)
 	if err != nil {
@@ -283,15 +411,15 @@ func runFullSchemaCheck(db *store.DB, migrationsDir, tempDir string, v semver.Ve
 	defer testDB.Close()
 
 	// run the migrations
-	baseLogger.Println("running migrations...")
+	baseLogger.Info("running migrations...")
 
-	err = testDB.RunMigrations(dir)
+	err = testDB.RunMigrations(migrations, store.MigrationOptions{}, baseLogger.With("db", "test"))
 	if err != nil {
 		return fmt.Errorf("could not run migrations: %w", err)
 	}
-	baseLogger.Println("migrations applied.")
+	baseLogger.Info("migrations applied")
 
-	err = store.CompareMySQL(db, testDB, baseLogger, verboseLogger, saveDiff)
+	err = store.CompareMySQL(db, testDB, baseLogger, saveDiff)
 	if err != nil {
 		return fmt.Errorf("failed to run schema comparison: %w", err)
 	}
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestCheckTable(t *testing.T) {
+	tables := map[string]int64{
+		"users":             10,
+		"schema_migrations": 1,
+		"ir_incident":       5,
+	}
+
+	tests := []struct {
+		name  string
+		check string
+		table string
+	}{
+		{name: "qualified column", check: "users.props", table: "users"},
+		{name: "table and column", check: "users_props", table: "users"},
+		{name: "table with underscores", check: "schema_migrations", table: "schema_migrations"},
+		{name: "table with underscores and column", check: "ir_incident_channelids", table: "ir_incident"},
+		{name: "unknown table", check: "posts.props", table: "posts"},
+		{name: "unknown table and column", check: "posts_props", table: "posts_props"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := checkTable(tc.check, tables); got != tc.table {
+				t.Errorf("expected %s, got %s", tc.table, got)
+			}
+		})
+	}
+}
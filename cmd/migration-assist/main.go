@@ -21,14 +21,24 @@ var root = &cobra.Command{
 
 func main() {
 	root.PersistentFlags().Bool("verbose", false, "Becomes verbose")
+	root.PersistentFlags().String("log-format", "text", "Format of the logs: text or json")
+	root.PersistentFlags().String("log-file", "", "Appends the logs to the given file in addition to stderr")
+	root.PersistentFlags().String("audit-log", "migration-assist-audit.jsonl", "Appends every statement executed against the databases to the given file, empty disables it")
+	root.PersistentPreRunE = commands.OpenAuditLog
 
 	root.AddCommand(
 		commands.SourceCheckCmd(),
 		commands.TargetCheckCmd(),
 		commands.GeneratePgloaderConfigCmd(),
+		commands.CacheCmd(),
+		commands.AuditCmd(),
+		commands.PreflightCmd(),
+		commands.EstimateCmd(),
 	)
 
-	if err := root.Execute(); err != nil {
+	err := root.Execute()
+	commands.CloseAuditLog()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "An Error Occurred: %s\n", err.Error())
 		os.Exit(1)
 	}
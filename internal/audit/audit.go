@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+
+	KindExec   = "exec"
+	KindSelect = "select"
+	// KindMigration is a migration applied or rolled back by morph, which
+	// runs its statements on its own connections.
+	KindMigration = "migration"
+)
+
+// Record is a single statement executed against a database.
+type Record struct {
+	Time      time.Time `json:"time"`
+	DBType    string    `json:"db_type"`
+	Database  string    `json:"database"`
+	Kind      string    `json:"kind"`
+	Statement string    `json:"statement"`
+	Params    []any     `json:"params,omitempty"`
+	// Rows is the number of affected rows for the exec statements and the
+	// returned count for the select count statements, -1 if it's unknown.
+	Rows     int64         `json:"rows"`
+	Duration time.Duration `json:"duration"`
+	Outcome  string        `json:"outcome"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Log appends the records to a file as JSON lines. The file is never
+// truncated, so that it holds the history of every run.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Open returns the audit log at the given path, the file is opened for
+// appending once the first record is written.
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+// Write appends the record to the log.
+func (l *Log) Write(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal audit record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		l.file, err = os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("could not open audit log: %w", err)
+		}
+	}
+
+	_, err = l.file.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("could not write audit record: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
+
+// Read returns the records of the audit log at the given path.
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("could not parse audit record at line %d: %w", line, err)
+		}
+		records = append(records, r)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read audit log: %w", err)
+	}
+
+	return records, nil
+}
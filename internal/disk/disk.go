@@ -0,0 +1,9 @@
+// Package disk reports the capacity of the file systems.
+package disk
+
+// Usage is the capacity of the file system that a path belongs to.
+type Usage struct {
+	Total uint64
+	// Free is the space that is available to unprivileged users.
+	Free uint64
+}
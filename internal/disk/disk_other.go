@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package disk
+
+import (
+	"errors"
+	"runtime"
+)
+
+// GetUsage returns the capacity of the file system that the path belongs to.
+func GetUsage(path string) (*Usage, error) {
+	return nil, errors.New("disk usage is not supported on " + runtime.GOOS)
+}
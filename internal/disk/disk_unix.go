@@ -0,0 +1,25 @@
+//go:build linux || darwin || freebsd
+
+package disk
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// GetUsage returns the capacity of the file system that the path belongs to.
+func GetUsage(path string) (*Usage, error) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat file system: %w", err)
+	}
+
+	// the field types differ between the platforms
+	bsize := uint64(st.Bsize)
+
+	return &Usage{
+		Total: uint64(st.Blocks) * bsize,
+		Free:  uint64(st.Bavail) * bsize,
+	}, nil
+}
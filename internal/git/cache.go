@@ -0,0 +1,245 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+const (
+	cacheDirName      = "migration-assist"
+	checksumExtension = ".sha256"
+)
+
+// CacheEntry is a set of migrations of a driver for a Mattermost version that
+// is stored in the cache directory.
+type CacheEntry struct {
+	Version string
+	Driver  string
+	Path    string
+	Files   int
+	Size    int64
+	Valid   bool
+}
+
+// CacheDir returns the directory that the migrations are cached in. It
+// respects $XDG_CACHE_HOME and falls back to the platform defaults.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, cacheDirName), nil
+}
+
+func cachePath(version semver.Version, driver string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "v"+version.String(), driver), nil
+}
+
+// ListCache returns the cached migrations along with their integrity status.
+func ListCache() ([]CacheEntry, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read cache directory: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, version := range versions {
+		if !version.IsDir() {
+			continue
+		}
+
+		drivers, err := os.ReadDir(filepath.Join(dir, version.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read cache directory: %w", err)
+		}
+
+		for _, driver := range drivers {
+			if !driver.IsDir() {
+				continue
+			}
+
+			entry := CacheEntry{
+				Version: version.Name(),
+				Driver:  driver.Name(),
+				Path:    filepath.Join(dir, version.Name(), driver.Name()),
+			}
+
+			files, err := os.ReadDir(entry.Path)
+			if err != nil {
+				return nil, fmt.Errorf("could not read cache directory: %w", err)
+			}
+			for _, f := range files {
+				info, err := f.Info()
+				if err != nil {
+					return nil, fmt.Errorf("could not stat cached file: %w", err)
+				}
+				entry.Files++
+				entry.Size += info.Size()
+			}
+
+			entry.Valid = verifyCache(entry.Path) == nil
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// PruneCache removes the cached migrations of a version, or the whole cache
+// if the version is empty.
+func PruneCache(version string) error {
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+
+	if version != "" {
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			return fmt.Errorf("could not parse version: %w", err)
+		}
+		dir = filepath.Join(dir, "v"+v.String())
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// storeCache moves the migrations in src into the cache and records their
+// checksum.
+func storeCache(src, path string) error {
+	_ = os.RemoveAll(path)
+	_ = os.Remove(path + checksumExtension)
+
+	err := os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	// the temporary directory may reside on another file system
+	if err = os.Rename(src, path); err != nil {
+		err = copyDir(src, path)
+		if err != nil {
+			return fmt.Errorf("could not move migrations into cache: %w", err)
+		}
+	}
+
+	sum, err := checksum(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path+checksumExtension, []byte(sum+"\n"), 0600)
+}
+
+// verifyCache compares the checksum of the cached migrations with the one
+// recorded while storing them.
+func verifyCache(path string) error {
+	expected, err := os.ReadFile(path + checksumExtension)
+	if err != nil {
+		return fmt.Errorf("could not read checksum: %w", err)
+	}
+
+	actual, err := checksum(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(string(expected)) != actual {
+		return fmt.Errorf("checksum mismatch for %s", path)
+	}
+
+	return nil
+}
+
+// checksum calculates a digest of the names and the contents of the files
+// within the directory.
+func checksum(dir string) (string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read directory: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return "", fmt.Errorf("could not open file: %w", err)
+		}
+
+		fmt.Fprintf(h, "%s\n", name)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("could not read file: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyDir copies the files of src into dst, dst is removed beforehand.
+func copyDir(src, dst string) error {
+	err := os.RemoveAll(dst)
+	if err != nil {
+		return fmt.Errorf("could not clear directory: %w", err)
+	}
+
+	err = os.MkdirAll(dst, 0750)
+	if err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+
+	files, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("could not read directory: %w", err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(src, f.Name()))
+		if err != nil {
+			return fmt.Errorf("could not read file: %w", err)
+		}
+
+		err = os.WriteFile(filepath.Join(dst, f.Name()), b, 0600)
+		if err != nil {
+			return fmt.Errorf("could not write file: %w", err)
+		}
+	}
+
+	return nil
+}
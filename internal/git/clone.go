@@ -3,9 +3,6 @@ package git
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/isacikgoz/migration-assist/internal/logger"
@@ -17,71 +14,74 @@ const (
 )
 
 type CloneOptions struct {
-	TempRepoPath string
-	DriverType   string
-	Output       string
-	Version      semver.Version
+	DriverType string
+	Output     string
+	Version    semver.Version
+	// Source is where the migrations are fetched from, the git CLI is used
+	// if it's not set.
+	Source MigrationSource
 }
 
-func CloneMigrations(opts CloneOptions, baseLogger logger.LogInterface) error {
-	// 1. first check if the git installedd
-	_, err := exec.LookPath(gitBinary)
-	if err != nil {
-		return fmt.Errorf("git binary is not installed :%w", err)
+func CloneMigrations(opts CloneOptions, baseLogger *logger.Logger) error {
+	src := opts.Source
+	if src == nil {
+		src = &GitSource{Binary: gitBinary}
 	}
 
-	// 2. clone the repository
-	gitArgs := []string{"clone", "--no-checkout", "--depth=1", "--filter=tree:0", fmt.Sprintf("--branch=%s", fmt.Sprintf("v%s", opts.Version.String())), mattermostRepositoryURL, opts.TempRepoPath}
-
-	cmd := exec.Command("git", gitArgs...)
+	// a local checkout is not cached as it can change between runs
+	if _, ok := src.(*LocalSource); ok {
+		if err := src.Fetch(opts.Version, opts.DriverType, opts.Output, baseLogger); err != nil {
+			return fmt.Errorf("error while fetching migrations: %w", err)
+		}
+		return nil
+	}
 
-	err = cmd.Run()
+	// 1. check if the migrations are already cached
+	cached, err := cachePath(opts.Version, opts.DriverType)
 	if err != nil {
-		return fmt.Errorf("error during clone: %w", err)
+		return err
 	}
 
-	// 3. download only migration files
-	v8 := semver.MustParse("8.0.0")
-	migrationsDir := filepath.Join("server", "channels", "db", "migrations", opts.DriverType)
-	if opts.Version.LT(v8) {
-		migrationsDir = strings.TrimPrefix(migrationsDir, filepath.Join("server", "channels"))
+	if _, err2 := os.Stat(cached); err2 == nil {
+		err2 = verifyCache(cached)
+		if err2 == nil {
+			baseLogger.Info("using cached migrations", "dir", cached)
+			return copyMigrations(cached, opts.Output, baseLogger)
+		}
+		baseLogger.Warn("cached migrations are invalid, fetching again", "err", err2)
 	}
 
-	baseLogger.Printf("checking out...\n")
-	cmd = exec.Command(gitBinary, "sparse-checkout", "set", "--no-cone", migrationsDir)
-	cmd.Dir = opts.TempRepoPath
-
-	err = cmd.Run()
+	// 2. fetch the migrations into a temporary directory
+	tempDir, err := os.MkdirTemp("", "mattermost")
 	if err != nil {
-		return fmt.Errorf("error during sparse checkout: %w", err)
+		return fmt.Errorf("could not create temp directory: %w", err)
 	}
+	defer os.RemoveAll(tempDir)
 
-	cmd = exec.Command(gitBinary, "checkout")
-	cmd.Dir = opts.TempRepoPath
-
-	err = cmd.Run()
+	err = src.Fetch(opts.Version, opts.DriverType, tempDir, baseLogger)
 	if err != nil {
-		return fmt.Errorf("error during checkout: %w", err)
+		return fmt.Errorf("error while fetching migrations: %w", err)
 	}
 
-	if _, err2 := os.Stat(opts.Output); err2 == nil || os.IsExist(err2) {
-		baseLogger.Println("removing existing migrations...")
-		err2 = os.RemoveAll(opts.Output)
-		if err2 != nil {
-			return fmt.Errorf("error clearing output directory: %w", err)
-		}
+	// 3. move files to the cache
+	baseLogger.Debug("caching migration files", "dir", cached)
+	err = storeCache(tempDir, cached)
+	if err != nil {
+		return fmt.Errorf("error while caching migrations: %w", err)
 	}
 
-	baseLogger.Printf("moving migration files into a better place..\n")
-	// 4. move files to migrations directory and remove temp dir
-	err = os.Rename(filepath.Join(opts.TempRepoPath, migrationsDir), opts.Output)
-	if err != nil {
-		return fmt.Errorf("error while renaming migrations directory: %w", err)
+	return copyMigrations(cached, opts.Output, baseLogger)
+}
+
+func copyMigrations(src, output string, baseLogger *logger.Logger) error {
+	if _, err := os.Stat(output); err == nil || os.IsExist(err) {
+		baseLogger.Debug("removing existing migrations...")
 	}
 
-	err = os.RemoveAll(opts.TempRepoPath)
+	baseLogger.Debug("copying migration files into a better place...")
+	err := copyDir(src, output)
 	if err != nil {
-		return fmt.Errorf("error while removing temporary directory: %w", err)
+		return fmt.Errorf("error while copying migrations directory: %w", err)
 	}
 
 	return nil
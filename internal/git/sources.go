@@ -0,0 +1,330 @@
+package git
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+)
+
+const (
+	mattermostContentsURL = "https://api.github.com/repos/mattermost/mattermost/contents/%s?ref=v%s"
+	mattermostTarballURL  = "https://github.com/mattermost/mattermost/archive/refs/tags/v%s.tar.gz"
+
+	// githubTimeout limits each request of the GitHub API, and tarballTimeout
+	// limits the download of the whole release archive.
+	githubTimeout  = time.Minute
+	tarballTimeout = 15 * time.Minute
+)
+
+// newHTTPClient returns a client that gives up on the unresponsive servers
+// rather than hanging, the timeout covers reading the response body.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+		},
+	}
+}
+
+// MigrationSource fetches the migrations of a Mattermost version.
+type MigrationSource interface {
+	// Fetch writes the migration files of the driver into the dst directory.
+	Fetch(version semver.Version, driver, dst string, baseLogger *logger.Logger) error
+}
+
+// NewMigrationSource returns the source of the given kind. The binary is used
+// by the git source and the path is used by the local source.
+func NewMigrationSource(kind, binary, path string) (MigrationSource, error) {
+	switch kind {
+	case "", "git":
+		if binary == "" {
+			binary = gitBinary
+		}
+		return &GitSource{Binary: binary}, nil
+	case "github-api":
+		return &GitHubAPISource{Client: newHTTPClient(githubTimeout)}, nil
+	case "tarball":
+		return &TarballSource{Client: newHTTPClient(tarballTimeout)}, nil
+	case "local":
+		if path == "" {
+			return nil, errors.New("path of the local repository is required")
+		}
+		return &LocalSource{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported migrations source: %s", kind)
+	}
+}
+
+// migrationsPath returns the path of the migrations within the repository.
+// Before v8 the server code was at the root of the repository.
+func migrationsPath(version semver.Version, driver string) string {
+	v8 := semver.MustParse("8.0.0")
+	if version.LT(v8) {
+		return path.Join("db", "migrations", driver)
+	}
+
+	return path.Join("server", "channels", "db", "migrations", driver)
+}
+
+// GitSource fetches the migrations with a sparse checkout using the git CLI.
+type GitSource struct {
+	Binary string
+}
+
+func (s *GitSource) Fetch(version semver.Version, driver, dst string, baseLogger *logger.Logger) error {
+	// 1. first check if the git installed
+	_, err := exec.LookPath(s.Binary)
+	if err != nil {
+		return fmt.Errorf("git binary is not installed :%w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "mattermost")
+	if err != nil {
+		return fmt.Errorf("could not create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 2. clone the repository
+	gitArgs := []string{"clone", "--no-checkout", "--depth=1", "--filter=tree:0", fmt.Sprintf("--branch=v%s", version.String()), mattermostRepositoryURL, tempDir}
+
+	cmd := exec.Command(s.Binary, gitArgs...)
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error during clone: %w", err)
+	}
+
+	// 3. download only migration files
+	migrationsDir := migrationsPath(version, driver)
+
+	baseLogger.Debug("checking out...")
+	cmd = exec.Command(s.Binary, "sparse-checkout", "set", "--no-cone", migrationsDir)
+	cmd.Dir = tempDir
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error during sparse checkout: %w", err)
+	}
+
+	cmd = exec.Command(s.Binary, "checkout")
+	cmd.Dir = tempDir
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error during checkout: %w", err)
+	}
+
+	return copyDir(filepath.Join(tempDir, filepath.FromSlash(migrationsDir)), dst)
+}
+
+// GitHubAPISource fetches the migrations over the GitHub contents API without
+// cloning the repository, hence it doesn't require a git binary. It's not a
+// git client and doesn't replace a pure-Go git fetch: it only supports the
+// Mattermost repository on GitHub, mirrors and other remotes can't be used,
+// and the API rate limits and the listing size of the contents API apply.
+// The GITHUB_TOKEN environment variable is used to authenticate if it's set,
+// which raises the limits.
+type GitHubAPISource struct {
+	Client *http.Client
+}
+
+type githubContent struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	DownloadURL string `json:"download_url"`
+}
+
+func (s *GitHubAPISource) Fetch(version semver.Version, driver, dst string, baseLogger *logger.Logger) error {
+	body, err := s.get(fmt.Sprintf(mattermostContentsURL, migrationsPath(version, driver), version.String()))
+	if err != nil {
+		return fmt.Errorf("could not list migrations: %w", err)
+	}
+	defer body.Close()
+
+	var contents []githubContent
+	err = json.NewDecoder(body).Decode(&contents)
+	if err != nil {
+		return fmt.Errorf("could not decode migrations list: %w", err)
+	}
+
+	err = os.MkdirAll(dst, 0750)
+	if err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+
+	baseLogger.Debug(fmt.Sprintf("downloading %d files...", len(contents)))
+	for _, c := range contents {
+		if c.Type != "file" {
+			continue
+		}
+
+		err = s.download(c.DownloadURL, filepath.Join(dst, c.Name))
+		if err != nil {
+			return fmt.Errorf("could not download %s: %w", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *GitHubAPISource) download(url, file string) error {
+	body, err := s.get(url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, body)
+	return err
+}
+
+func (s *GitHubAPISource) get(url string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return httpGet(s.Client, req)
+}
+
+// TarballSource fetches the migrations from the source archive of the release.
+type TarballSource struct {
+	Client *http.Client
+}
+
+func (s *TarballSource) Fetch(version semver.Version, driver, dst string, baseLogger *logger.Logger) error {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(mattermostTarballURL, version.String()), nil)
+	if err != nil {
+		return err
+	}
+
+	baseLogger.Debug("downloading release archive...")
+	body, err := httpGet(s.Client, req)
+	if err != nil {
+		return fmt.Errorf("could not download archive: %w", err)
+	}
+	defer body.Close()
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("could not read archive: %w", err)
+	}
+	defer gz.Close()
+
+	err = os.MkdirAll(dst, 0750)
+	if err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+
+	migrationsDir := migrationsPath(version, driver)
+	tr := tar.NewReader(gz)
+
+	var count int
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("could not read archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// the entries are prefixed with the name of the archive, e.g. mattermost-9.7.0/
+		_, name, ok := strings.Cut(hdr.Name, "/")
+		if !ok || path.Dir(name) != migrationsDir {
+			continue
+		}
+
+		f, err := os.Create(filepath.Join(dst, path.Base(name)))
+		if err != nil {
+			return fmt.Errorf("could not create file: %w", err)
+		}
+
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not extract %s: %w", name, err)
+		}
+		count++
+	}
+
+	if count == 0 {
+		return fmt.Errorf("no migrations found in the archive for %s", driver)
+	}
+
+	return nil
+}
+
+// LocalSource reads the migrations from a local checkout of the Mattermost
+// repository. The checkout is used as is, regardless of the version.
+type LocalSource struct {
+	Path string
+}
+
+func (s *LocalSource) Fetch(version semver.Version, driver, dst string, baseLogger *logger.Logger) error {
+	// the layout of the checkout may differ from the requested version
+	candidates := []string{
+		migrationsPath(version, driver),
+		migrationsPath(semver.Version{Major: 8}, driver),
+		migrationsPath(semver.Version{Major: 7}, driver),
+	}
+
+	for _, candidate := range candidates {
+		dir := filepath.Join(s.Path, filepath.FromSlash(candidate))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			baseLogger.Info("using migrations from local checkout", "dir", dir)
+			return copyDir(dir, dst)
+		}
+	}
+
+	return fmt.Errorf("could not find %s migrations in %s", driver, s.Path)
+}
+
+func httpGet(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return nil, fmt.Errorf("GitHub API rate limit exceeded, set GITHUB_TOKEN or use another migrations source: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
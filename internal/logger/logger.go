@@ -1,50 +1,118 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
-	"time"
 )
 
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// LogInterface is the logger that the third party libraries such as morph
+// and testcontainers expect.
 type LogInterface interface {
 	Printf(format string, v ...interface{})
 	Println(v ...any)
 }
 
 type Options struct {
-	Timestamps bool
+	// Format is either text or json, text is used if it's empty.
+	Format string
+	// Level is the minimum level of the records to be written.
+	Level slog.Level
+	// File is the path of a file that the records are appended to, in
+	// addition to the writer.
+	File string
+}
+
+// Logger is a leveled logger built on log/slog. The records are written to
+// the writer and to the log file if it's set.
+type Logger struct {
+	*slog.Logger
+
+	closer io.Closer
 }
 
-func NewLogger(w io.Writer, opts Options) *Logger {
+func NewLogger(w io.Writer, opts Options) (*Logger, error) {
 	if w == nil {
 		w = os.Stderr
 	}
-	return &Logger{
-		Writer:     w,
-		Timestamps: opts.Timestamps,
+
+	l := &Logger{}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("could not open log file: %w", err)
+		}
+		l.closer = f
+		w = io.MultiWriter(w, f)
 	}
+
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	switch opts.Format {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", opts.Format)
+	}
+
+	l.Logger = slog.New(handler)
+
+	return l, nil
 }
 
-type Logger struct {
-	Writer     io.Writer
-	Timestamps bool
+// With returns a logger that attaches the given fields to each record.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{
+		Logger: l.Logger.With(args...),
+		closer: l.closer,
+	}
 }
 
+// Printf writes the formatted message as an info record.
 func (l *Logger) Printf(format string, v ...interface{}) {
-	if l.Timestamps {
-		format = time.Now().Format(time.DateTime) + " " + format
-	}
-	if !strings.HasSuffix(format, "\n") {
-		format += "\n"
-	}
-	fmt.Fprintf(l.Writer, format, v...)
+	l.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
 }
 
+// Println writes the message as an info record.
 func (l *Logger) Println(v ...any) {
-	if l.Timestamps {
-		v = append([]any{time.Now().Format(time.DateTime)}, v...)
+	l.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Printer returns a LogInterface that writes the messages at the given level,
+// it's used to pass the logger to the third party libraries.
+func (l *Logger) Printer(level slog.Level) LogInterface {
+	return &printer{logger: l.Logger, level: level}
+}
+
+// Close closes the log file if there is one.
+func (l *Logger) Close() error {
+	if l.closer == nil {
+		return nil
 	}
-	fmt.Fprintln(l.Writer, v...)
+
+	return l.closer.Close()
+}
+
+type printer struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+func (p *printer) Printf(format string, v ...interface{}) {
+	p.logger.Log(context.Background(), p.level, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (p *printer) Println(v ...any) {
+	p.logger.Log(context.Background(), p.level, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
 }
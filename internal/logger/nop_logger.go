@@ -1,11 +1,13 @@
 package logger
 
-func NewNopLogger() *NopLogger {
-	return &NopLogger{}
+import (
+	"io"
+	"log/slog"
+)
+
+// NewNopLogger returns a logger that discards every record.
+func NewNopLogger() *Logger {
+	return &Logger{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
 }
-
-type NopLogger struct{}
-
-func (l *NopLogger) Printf(format string, v ...interface{}) {}
-
-func (l *NopLogger) Println(v ...interface{}) {}
@@ -0,0 +1,199 @@
+package pgloader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+const removeNullCharacters = "remove-null-characters"
+
+// castRule is a column level CAST rule of a pgloader configuration.
+type castRule struct {
+	Table  string
+	Column string
+	Type   string
+	Using  string
+}
+
+func (c castRule) String() string {
+	s := fmt.Sprintf("column %s.%s to %s drop typemod", c.Table, c.Column, c.Type)
+	if c.Using != "" {
+		s += " using " + c.Using
+	}
+	return s
+}
+
+// generateCastRules compares the MySQL source schema with the Postgres target
+// schema and derives the column casts that pgloader can't figure out by itself.
+// Columns that can't be reconciled are reported with the logger.
+func generateCastRules(ctx context.Context, mysqlDB, postgresDB *store.DB, schema string, removeNull bool, baseLogger *logger.Logger) ([]castRule, error) {
+	source, err := mysqlDB.GetColumns(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not read mysql columns: %w", err)
+	}
+
+	target, err := postgresDB.GetColumns(ctx, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not read postgres columns: %w", err)
+	}
+
+	targetTables := make(map[string]bool)
+	targetColumns := make(map[string]store.Column)
+	for _, c := range target {
+		targetTables[strings.ToLower(c.Table)] = true
+		targetColumns[columnKey(c)] = c
+	}
+
+	var rules []castRule
+	for _, src := range source {
+		// tables that don't exist in the target are not part of the load
+		if !targetTables[strings.ToLower(src.Table)] {
+			continue
+		}
+
+		dst, ok := targetColumns[columnKey(src)]
+		if !ok {
+			baseLogger.Warn("column does not exist in the target schema", "table", src.Table, "column", src.Name)
+			continue
+		}
+
+		rule, ok := reconcile(src, dst, removeNull)
+		if !ok {
+			baseLogger.Warn("column can't be converted", "table", src.Table, "column", src.Name, "from", src.ColumnType, "to", dst.DataType)
+			continue
+		}
+		if rule != nil {
+			rules = append(rules, *rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// reconcile returns the cast rule required to load the src column into dst.
+// A nil rule means that pgloader's default casting is sufficient.
+func reconcile(src, dst store.Column, removeNull bool) (*castRule, bool) {
+	rule := &castRule{Table: src.Table, Column: src.Name}
+	using := ""
+	if removeNull {
+		using = removeNullCharacters
+	}
+
+	switch {
+	case src.DataType == "enum":
+		switch postgresFamily(dst) {
+		case "enum":
+			rule.Type = fmt.Sprintf("%q", dst.ColumnType)
+		case "text":
+			rule.Type = "text"
+		default:
+			return nil, false
+		}
+	case src.DataType == "tinyint" && dst.DataType == "boolean":
+		rule.Type = "boolean"
+		rule.Using = "tinyint-to-boolean"
+	case strings.HasPrefix(src.ColumnType, "tinyint(1)") && postgresFamily(dst) == "integer":
+		// pgloader casts tinyint(1) into boolean by default
+		rule.Type = dst.DataType
+	case src.DataType == "json" || dst.DataType == "jsonb" || dst.DataType == "json":
+		switch dst.DataType {
+		case "jsonb":
+			rule.Type = "jsonb"
+		case "json":
+			rule.Type = `"json"`
+		default:
+			if postgresFamily(dst) != "text" {
+				return nil, false
+			}
+			rule.Type = "text"
+		}
+		if mysqlFamily(src) != "text" {
+			return nil, false
+		}
+		rule.Using = using
+	default:
+		if mysqlFamily(src) != postgresFamily(dst) {
+			return nil, false
+		}
+		return nil, true
+	}
+
+	return rule, true
+}
+
+func columnKey(c store.Column) string {
+	return strings.ToLower(c.Table) + "." + strings.ToLower(c.Name)
+}
+
+func mysqlFamily(c store.Column) string {
+	switch c.DataType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return "integer"
+	case "decimal", "float", "double":
+		return "numeric"
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json":
+		return "text"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "binary"
+	case "date", "datetime", "timestamp", "time", "year":
+		return "time"
+	default:
+		return c.DataType
+	}
+}
+
+func postgresFamily(c store.Column) string {
+	switch c.DataType {
+	case "smallint", "integer", "bigint":
+		return "integer"
+	case "numeric", "real", "double precision":
+		return "numeric"
+	case "character", "character varying", "text", "json", "jsonb":
+		return "text"
+	case "USER-DEFINED":
+		return "enum"
+	case "bytea":
+		return "binary"
+	case "date", "timestamp without time zone", "timestamp with time zone", "time without time zone", "time with time zone":
+		return "time"
+	default:
+		return c.DataType
+	}
+}
+
+// JSONColumns returns the MySQL columns that are cast to json or jsonb, as
+// they are loaded into the Postgres schema.
+func JSONColumns(ctx context.Context, mysqlDB, postgresDB *store.DB, schema string) ([]store.Column, error) {
+	source, err := mysqlDB.GetColumns(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not read mysql columns: %w", err)
+	}
+
+	target, err := postgresDB.GetColumns(ctx, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not read postgres columns: %w", err)
+	}
+
+	targetColumns := make(map[string]store.Column)
+	for _, c := range target {
+		targetColumns[columnKey(c)] = c
+	}
+
+	var columns []store.Column
+	for _, src := range source {
+		dst, ok := targetColumns[columnKey(src)]
+		if !ok || mysqlFamily(src) != "text" {
+			continue
+		}
+
+		if dst.DataType == "jsonb" || dst.DataType == "json" {
+			columns = append(columns, src)
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,117 @@
+package pgloader
+
+import (
+	"testing"
+
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+func TestReconcile(t *testing.T) {
+	column := func(dataType, columnType string) store.Column {
+		return store.Column{Table: "Posts", Name: "Col", DataType: dataType, ColumnType: columnType}
+	}
+
+	tests := []struct {
+		name       string
+		src        store.Column
+		dst        store.Column
+		removeNull bool
+		rule       string
+		ok         bool
+	}{
+		{
+			name: "enum into enum type",
+			src:  column("enum", "enum('O','P')"),
+			dst:  column("USER-DEFINED", "channel_type"),
+			rule: `column Posts.Col to "channel_type" drop typemod`,
+			ok:   true,
+		},
+		{
+			name: "enum into text",
+			src:  column("enum", "enum('O','P')"),
+			dst:  column("character varying", "varchar"),
+			rule: "column Posts.Col to text drop typemod",
+			ok:   true,
+		},
+		{
+			name: "enum into integer",
+			src:  column("enum", "enum('O','P')"),
+			dst:  column("integer", "int4"),
+		},
+		{
+			name: "tinyint(1) into boolean",
+			src:  column("tinyint", "tinyint(1)"),
+			dst:  column("boolean", "bool"),
+			rule: "column Posts.Col to boolean drop typemod using tinyint-to-boolean",
+			ok:   true,
+		},
+		{
+			name: "tinyint(1) into smallint",
+			src:  column("tinyint", "tinyint(1)"),
+			dst:  column("smallint", "int2"),
+			rule: "column Posts.Col to smallint drop typemod",
+			ok:   true,
+		},
+		{
+			name: "tinyint(4) into smallint",
+			src:  column("tinyint", "tinyint(4)"),
+			dst:  column("smallint", "int2"),
+			ok:   true,
+		},
+		{
+			name: "json into jsonb",
+			src:  column("json", "json"),
+			dst:  column("jsonb", "jsonb"),
+			rule: "column Posts.Col to jsonb drop typemod",
+			ok:   true,
+		},
+		{
+			name:       "text into jsonb without null characters",
+			src:        column("text", "text"),
+			dst:        column("jsonb", "jsonb"),
+			removeNull: true,
+			rule:       "column Posts.Col to jsonb drop typemod using remove-null-characters",
+			ok:         true,
+		},
+		{
+			name: "text into json",
+			src:  column("text", "text"),
+			dst:  column("json", "json"),
+			rule: `column Posts.Col to "json" drop typemod`,
+			ok:   true,
+		},
+		{
+			name: "blob into jsonb",
+			src:  column("blob", "blob"),
+			dst:  column("jsonb", "jsonb"),
+		},
+		{
+			name: "varchar into text",
+			src:  column("varchar", "varchar(26)"),
+			dst:  column("text", "text"),
+			ok:   true,
+		},
+		{
+			name: "varchar into integer",
+			src:  column("varchar", "varchar(26)"),
+			dst:  column("bigint", "int8"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, ok := reconcile(tc.src, tc.dst, tc.removeNull)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %t, got %t", tc.ok, ok)
+			}
+
+			var got string
+			if rule != nil {
+				got = rule.String()
+			}
+			if got != tc.rule {
+				t.Errorf("expected rule %q, got %q", tc.rule, got)
+			}
+		})
+	}
+}
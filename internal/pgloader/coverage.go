@@ -0,0 +1,120 @@
+package pgloader
+
+import (
+	"strings"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+)
+
+// TableCoverage describes how a MySQL table is handled by the configurations.
+type TableCoverage struct {
+	Table string
+	// LoadedBy lists the products whose configuration loads the table.
+	LoadedBy []string
+	// SkippedBy is the pattern that excludes the table on purpose.
+	SkippedBy string
+}
+
+// CoverageReport is the result of evaluating the configuration of every
+// product against the tables of the MySQL database.
+type CoverageReport struct {
+	Tables []TableCoverage
+}
+
+// LoadedTwice returns the tables that are loaded by more than one configuration.
+func (r *CoverageReport) LoadedTwice() []TableCoverage {
+	var tables []TableCoverage
+	for _, t := range r.Tables {
+		if len(t.LoadedBy) > 1 {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// NeverLoaded returns the tables that are neither loaded nor skipped on purpose.
+func (r *CoverageReport) NeverLoaded() []TableCoverage {
+	var tables []TableCoverage
+	for _, t := range r.Tables {
+		if len(t.LoadedBy) == 0 && t.SkippedBy == "" {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// Skipped returns the tables that are excluded on purpose.
+func (r *CoverageReport) Skipped() []TableCoverage {
+	var tables []TableCoverage
+	for _, t := range r.Tables {
+		if len(t.LoadedBy) == 0 && t.SkippedBy != "" {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// CheckCoverage evaluates the table filters of every product configuration
+// against the tables of the MySQL database.
+func CheckCoverage(config PgLoaderConfig, baseLogger *logger.Logger) (*CoverageReport, error) {
+	tables, err := mysqlTables(config.MySQLDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := renderProductConfigs(config, tables, baseLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluateCoverage(configs, tables), nil
+}
+
+func evaluateCoverage(configs []productConfig, tables []string) *CoverageReport {
+	report := &CoverageReport{}
+	for _, table := range tables {
+		coverage := TableCoverage{Table: table}
+		for _, c := range configs {
+			if c.filter.includes(table) {
+				coverage.LoadedBy = append(coverage.LoadedBy, productName(c.product))
+			}
+		}
+
+		if len(coverage.LoadedBy) == 0 {
+			for _, c := range configs {
+				p, ok := c.filter.excludedBy(table)
+				if ok && !isDelegated(p, configs) {
+					coverage.SkippedBy = p.String()
+					break
+				}
+			}
+		}
+
+		report.Tables = append(report.Tables, coverage)
+	}
+
+	return report
+}
+
+// isDelegated reports whether an excluding pattern hands the tables over to
+// another configuration, e.g. ~<IR_> of the main configuration is loaded by
+// ~/IR_/ of the playbooks configuration.
+func isDelegated(p tablePattern, configs []productConfig) bool {
+	for _, c := range configs {
+		for _, inc := range c.filter.including {
+			if strings.EqualFold(inc.String(), p.String()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func coverageTableNames(tables []TableCoverage) []string {
+	names := make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, t.Table)
+	}
+	return names
+}
@@ -0,0 +1,103 @@
+package pgloader
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvaluateCoverage(t *testing.T) {
+	mustParse := func(config string) tableFilter {
+		t.Helper()
+		filter, err := parseTableFilters(config)
+		if err != nil {
+			t.Fatalf("could not parse filters: %v", err)
+		}
+		return filter
+	}
+
+	configs := []productConfig{
+		{
+			product: "",
+			filter:  mustParse("EXCLUDING TABLE NAMES MATCHING ~<IR_>, 'db_migrations'\n\n"),
+		},
+		{
+			product: "playbooks",
+			filter:  mustParse("INCLUDING ONLY TABLE NAMES MATCHING ~/IR_/\n\n"),
+		},
+		{
+			product: "boards",
+			filter:  mustParse("INCLUDING ONLY TABLE NAMES MATCHING 'focalboard_blocks', 'Users'\n\n"),
+		},
+	}
+
+	tests := []struct {
+		table     string
+		loadedBy  []string
+		skippedBy string
+	}{
+		{table: "Posts", loadedBy: []string{"mattermost"}},
+		{table: "IR_Incident", loadedBy: []string{"playbooks"}},
+		{table: "focalboard_blocks", loadedBy: []string{"mattermost", "boards"}},
+		{table: "Users", loadedBy: []string{"mattermost", "boards"}},
+		{table: "db_migrations", skippedBy: "'db_migrations'"},
+	}
+
+	tables := make([]string, 0, len(tests))
+	for _, tc := range tests {
+		tables = append(tables, tc.table)
+	}
+
+	report := evaluateCoverage(configs, tables)
+	if len(report.Tables) != len(tests) {
+		t.Fatalf("expected %d tables, got %d", len(tests), len(report.Tables))
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.table, func(t *testing.T) {
+			got := report.Tables[i]
+			if got.Table != tc.table {
+				t.Fatalf("expected table %s, got %s", tc.table, got.Table)
+			}
+			if !slices.Equal(got.LoadedBy, tc.loadedBy) {
+				t.Errorf("expected to be loaded by %v, got %v", tc.loadedBy, got.LoadedBy)
+			}
+			if got.SkippedBy != tc.skippedBy {
+				t.Errorf("expected to be skipped by %q, got %q", tc.skippedBy, got.SkippedBy)
+			}
+		})
+	}
+
+	if got := coverageTableNames(report.LoadedTwice()); !slices.Equal(got, []string{"focalboard_blocks", "Users"}) {
+		t.Errorf("unexpected tables loaded twice: %v", got)
+	}
+	if got := coverageTableNames(report.Skipped()); !slices.Equal(got, []string{"db_migrations"}) {
+		t.Errorf("unexpected skipped tables: %v", got)
+	}
+	if got := report.NeverLoaded(); len(got) != 0 {
+		t.Errorf("unexpected tables that are never loaded: %v", coverageTableNames(got))
+	}
+}
+
+func TestEvaluateCoverageNeverLoaded(t *testing.T) {
+	excluding, err := parseTableFilters("EXCLUDING TABLE NAMES MATCHING ~<IR_>\n\n")
+	if err != nil {
+		t.Fatalf("could not parse filters: %v", err)
+	}
+
+	including, err := parseTableFilters("INCLUDING ONLY TABLE NAMES MATCHING 'focalboard_blocks'\n\n")
+	if err != nil {
+		t.Fatalf("could not parse filters: %v", err)
+	}
+
+	// none of the configurations includes the excluded tables, hence they
+	// are skipped on purpose rather than being handed over.
+	report := evaluateCoverage([]productConfig{{filter: excluding}}, []string{"IR_Incident"})
+	if got := coverageTableNames(report.Skipped()); !slices.Equal(got, []string{"IR_Incident"}) {
+		t.Errorf("unexpected skipped tables: %v", got)
+	}
+
+	report = evaluateCoverage([]productConfig{{product: "boards", filter: including}}, []string{"focalboard_blocks", "focalboard_cards"})
+	if got := coverageTableNames(report.NeverLoaded()); !slices.Equal(got, []string{"focalboard_cards"}) {
+		t.Errorf("unexpected tables that are never loaded: %v", got)
+	}
+}
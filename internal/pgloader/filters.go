@@ -0,0 +1,136 @@
+package pgloader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	includingClause = regexp.MustCompile(`(?s)INCLUDING ONLY TABLE NAMES MATCHING(.*?)(?:\n\s*\n|;|$)`)
+	excludingClause = regexp.MustCompile(`(?s)EXCLUDING TABLE NAMES MATCHING(.*?)(?:\n\s*\n|;|$)`)
+
+	// closing delimiters of the regular expressions supported by pgloader
+	regexDelimiters = map[byte]byte{'/': '/', '<': '>', '[': ']', '{': '}', '(': ')', '|': '|', '"': '"', '\'': '\''}
+)
+
+// tablePattern is a single pattern of an INCLUDING or EXCLUDING clause. It is
+// either a literal table name or a regular expression.
+type tablePattern struct {
+	literal string
+	regex   *regexp.Regexp
+}
+
+func (p tablePattern) match(table string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(table)
+	}
+	// the table names in the templates are written in lower case, hence
+	// we don't rely on the casing of the MySQL table names.
+	return strings.EqualFold(p.literal, table)
+}
+
+func (p tablePattern) String() string {
+	if p.regex != nil {
+		return "~/" + p.regex.String() + "/"
+	}
+	return "'" + p.literal + "'"
+}
+
+// tableFilter represents the table name filters of a pgloader configuration.
+type tableFilter struct {
+	including []tablePattern
+	excluding []tablePattern
+}
+
+// parseTableFilters reads the INCLUDING ONLY and EXCLUDING clauses from a
+// rendered pgloader configuration.
+func parseTableFilters(config string) (tableFilter, error) {
+	var filter tableFilter
+	var err error
+
+	if m := includingClause.FindStringSubmatch(config); m != nil {
+		filter.including, err = parsePatterns(m[1])
+		if err != nil {
+			return filter, fmt.Errorf("could not parse including clause: %w", err)
+		}
+	}
+
+	if m := excludingClause.FindStringSubmatch(config); m != nil {
+		filter.excluding, err = parsePatterns(m[1])
+		if err != nil {
+			return filter, fmt.Errorf("could not parse excluding clause: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// includes reports whether the table would be loaded with the filter.
+func (f tableFilter) includes(table string) bool {
+	if _, ok := f.excludedBy(table); ok {
+		return false
+	}
+
+	if len(f.including) == 0 {
+		return true
+	}
+
+	for _, p := range f.including {
+		if p.match(table) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludedBy returns the pattern that excludes the table, if any.
+func (f tableFilter) excludedBy(table string) (tablePattern, bool) {
+	for _, p := range f.excluding {
+		if p.match(table) {
+			return p, true
+		}
+	}
+
+	return tablePattern{}, false
+}
+
+func parsePatterns(clause string) ([]tablePattern, error) {
+	var patterns []tablePattern
+
+	s := strings.TrimSpace(clause)
+	for s != "" {
+		switch {
+		case s[0] == '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated table name: %s", s)
+			}
+			patterns = append(patterns, tablePattern{literal: s[1 : end+1]})
+			s = s[end+2:]
+		case s[0] == '~' && len(s) > 1:
+			closing, ok := regexDelimiters[s[1]]
+			if !ok {
+				return nil, fmt.Errorf("unsupported regular expression delimiter: %c", s[1])
+			}
+			end := strings.IndexByte(s[2:], closing)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated regular expression: %s", s)
+			}
+			re, err := regexp.Compile(s[2 : end+2])
+			if err != nil {
+				return nil, fmt.Errorf("could not compile regular expression: %w", err)
+			}
+			patterns = append(patterns, tablePattern{regex: re})
+			s = s[end+3:]
+		default:
+			return nil, fmt.Errorf("unexpected token: %s", s)
+		}
+
+		s = strings.TrimSpace(s)
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+	}
+
+	return patterns, nil
+}
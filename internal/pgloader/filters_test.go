@@ -0,0 +1,119 @@
+package pgloader
+
+import (
+	"testing"
+)
+
+func TestParsePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		clause   string
+		patterns []string
+		err      bool
+	}{
+		{
+			name:   "empty clause",
+			clause: "  \n ",
+		},
+		{
+			name:     "literals",
+			clause:   "'users', 'posts'",
+			patterns: []string{"'users'", "'posts'"},
+		},
+		{
+			name:     "slash delimited regular expression",
+			clause:   "~/^ir_/",
+			patterns: []string{"~/^ir_/"},
+		},
+		{
+			name:     "angle bracket delimited regular expression",
+			clause:   "~<^focalboard_>",
+			patterns: []string{"~/^focalboard_/"},
+		},
+		{
+			name:     "mixed patterns over multiple lines",
+			clause:   "\n  'db_migrations',\n  ~/^ir_/ ,\n  'systems'",
+			patterns: []string{"'db_migrations'", "~/^ir_/", "'systems'"},
+		},
+		{
+			name:   "unterminated table name",
+			clause: "'users",
+			err:    true,
+		},
+		{
+			name:   "unterminated regular expression",
+			clause: "~/^ir_",
+			err:    true,
+		},
+		{
+			name:   "unsupported delimiter",
+			clause: "~#^ir_#",
+			err:    true,
+		},
+		{
+			name:   "invalid regular expression",
+			clause: "~/(ir_/",
+			err:    true,
+		},
+		{
+			name:   "unexpected token",
+			clause: "users",
+			err:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			patterns, err := parsePatterns(tc.clause)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", patterns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(patterns) != len(tc.patterns) {
+				t.Fatalf("expected %d patterns, got %v", len(tc.patterns), patterns)
+			}
+			for i, p := range patterns {
+				if p.String() != tc.patterns[i] {
+					t.Errorf("expected pattern %s, got %s", tc.patterns[i], p)
+				}
+			}
+		})
+	}
+}
+
+func TestTableFilterIncludes(t *testing.T) {
+	config := `LOAD DATABASE
+INCLUDING ONLY TABLE NAMES MATCHING 'Users', ~/^ir_/
+
+EXCLUDING TABLE NAMES MATCHING 'ir_system'
+;`
+
+	filter, err := parseTableFilters(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		table    string
+		included bool
+	}{
+		{table: "users", included: true},
+		{table: "ir_playbook", included: true},
+		{table: "ir_system", included: false},
+		{table: "posts", included: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.table, func(t *testing.T) {
+			if got := filter.includes(tc.table); got != tc.included {
+				t.Errorf("expected includes to be %t, got %t", tc.included, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package pgloader
 
 import (
+	"context"
 	"embed"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strings"
 	"text/template"
 
 	"github.com/isacikgoz/migration-assist/internal/logger"
@@ -15,6 +17,10 @@ import (
 //go:embed templates
 var assets embed.FS
 
+// Workers is the number of pgloader workers of a load, each of them holds a
+// connection to both of the databases.
+const Workers = 8
+
 type parameters struct {
 	MySQLUser     string
 	MySQLPassword string
@@ -25,19 +31,58 @@ type parameters struct {
 	PGPassword   string
 	PGAddress    string
 	TargetSchema string
+	PGSchema     string
+	RenameSchema bool
 
 	RemoveNullCharacters bool
+	Workers              int
 	SearchPath           string
+	CastRules            []castRule
+
+	// the fields below are used while splitting the load into parts
+	IncludeTables []string
+	ExcludeTables []string
+	Truncate      bool
+	SkipLoadHooks bool
 }
 
 type PgLoaderConfig struct {
 	MySQLDSN    string
 	PostgresDSN string
+	// Schema is the Postgres schema that the data is loaded into
+	Schema string
 
 	RemoveNullCharacters bool
 }
 
-func GenerateConfigurationFile(output, product string, config PgLoaderConfig, baseLogger logger.LogInterface) error {
+func GenerateConfigurationFile(output, product string, config PgLoaderConfig, baseLogger *logger.Logger) error {
+	templ, params, err := prepareConfiguration(product, config, baseLogger)
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer
+	switch output {
+	case "":
+		writer = os.Stdout
+	default:
+		f, err2 := os.Create(output)
+		if err2 != nil {
+			return err2
+		}
+		defer f.Close()
+		writer = f
+	}
+
+	err = templ.Execute(writer, params)
+	if err != nil {
+		return fmt.Errorf("error during executing the template: %w", err)
+	}
+
+	return nil
+}
+
+func prepareConfiguration(product string, config PgLoaderConfig, baseLogger *logger.Logger) (*template.Template, parameters, error) {
 	var f string
 	switch product {
 	case "boards":
@@ -49,68 +94,110 @@ func GenerateConfigurationFile(output, product string, config PgLoaderConfig, ba
 	}
 	bytes, err := assets.ReadFile(fmt.Sprintf("templates/%s.tmpl", f))
 	if err != nil {
-		return fmt.Errorf("could not read configuration template: %w", err)
+		return nil, parameters{}, fmt.Errorf("could not read configuration template: %w", err)
 	}
 
 	templ, err := template.New("cfg").Parse(string(bytes))
 	if err != nil {
-		return fmt.Errorf("could not parse template: %w", err)
+		return nil, parameters{}, fmt.Errorf("could not parse template: %w", err)
+	}
+
+	schema := config.Schema
+	if schema == "" {
+		schema = "public"
 	}
 
 	params := parameters{
 		RemoveNullCharacters: config.RemoveNullCharacters,
+		Workers:              Workers,
+		PGSchema:             schema,
 	}
 	err = parseMySQL(&params, config.MySQLDSN)
 	if err != nil {
-		return fmt.Errorf("could not parse mysql DSN: %w", err)
+		return nil, params, fmt.Errorf("could not parse mysql DSN: %w", err)
 	}
 
 	err = parsePostgres(&params, config.PostgresDSN)
 	if err != nil {
-		return fmt.Errorf("could not parse postgres DSN: %w", err)
+		return nil, params, fmt.Errorf("could not parse postgres DSN: %w", err)
 	}
 
 	postgresDB, err := store.NewStore("postgres", config.PostgresDSN)
 	if err != nil {
-		return err
+		return nil, params, err
 	}
 	defer postgresDB.Close()
 
-	baseLogger.Println("pinging postgres...")
+	baseLogger.Info("pinging postgres...")
 	err = postgresDB.Ping()
 	if err != nil {
-		return fmt.Errorf("could not ping postgres: %w", err)
+		return nil, params, fmt.Errorf("could not ping postgres: %w", err)
 	}
-	baseLogger.Println("connected to postgres successfully.")
+	baseLogger.Info("connected to postgres successfully")
 
-	row := postgresDB.GetDB().QueryRow("SHOW SEARCH_PATH")
-	if row.Err() != nil {
-		return fmt.Errorf("could not query search path: %w", err)
+	exists, err := postgresDB.SchemaExists(context.TODO(), schema)
+	if err != nil {
+		return nil, params, fmt.Errorf("could not check schema: %w", err)
+	} else if !exists {
+		return nil, params, fmt.Errorf("schema %q does not exist in the target database", schema)
 	}
-	err = row.Scan(&params.SearchPath)
+
+	// pgloader loads the data into a schema named after the MySQL database,
+	// so the schema is renamed unless they are the same already.
+	params.RenameSchema = params.PGSchema != params.SourceSchema
+
+	// the main configuration loads data only, hence the casts should follow
+	// the schema that is already created by the migrations.
+	if f == "config" {
+		params.CastRules, err = castRulesFromSchema(config, params, postgresDB, baseLogger)
+		if err != nil {
+			return nil, params, err
+		}
+	}
+
+	params.SearchPath, err = postgresDB.SearchPath(context.TODO())
 	if err != nil {
-		return fmt.Errorf("could not query scan search path: %w", err)
+		return nil, params, fmt.Errorf("could not query search path: %w", err)
 	}
 
-	var writer io.Writer
-	switch output {
-	case "":
-		writer = os.Stdout
-	default:
-		f, err2 := os.Create(output)
-		if err2 != nil {
-			return err2
+	// the tables should be resolved within the schema after the load
+	if !inSearchPath(params.SearchPath, schema) {
+		params.SearchPath = schema + ", " + params.SearchPath
+	}
+
+	return templ, params, nil
+}
+
+func inSearchPath(searchPath, schema string) bool {
+	for _, s := range strings.Split(searchPath, ",") {
+		if strings.Trim(strings.TrimSpace(s), `"`) == schema {
+			return true
 		}
-		defer f.Close()
-		writer = f
 	}
 
-	err = templ.Execute(writer, params)
+	return false
+}
+
+func castRulesFromSchema(config PgLoaderConfig, params parameters, postgresDB *store.DB, baseLogger *logger.Logger) ([]castRule, error) {
+	mysqlDB, err := store.NewStore("mysql", config.MySQLDSN)
 	if err != nil {
-		return fmt.Errorf("error during executing the template: %w", err)
+		return nil, err
 	}
+	defer mysqlDB.Close()
 
-	return nil
+	baseLogger.Info("pinging mysql...")
+	err = mysqlDB.Ping()
+	if err != nil {
+		return nil, fmt.Errorf("could not ping mysql: %w", err)
+	}
+	baseLogger.Info("connected to mysql successfully")
+
+	rules, err := generateCastRules(context.TODO(), mysqlDB, postgresDB, params.PGSchema, config.RemoveNullCharacters, baseLogger)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate cast rules: %w", err)
+	}
+
+	return rules, nil
 }
 
 func parseMySQL(params *parameters, dsn string) error {
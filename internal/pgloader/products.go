@@ -0,0 +1,143 @@
+package pgloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+// products are listed in the order that the configurations should be loaded.
+// The main configuration should run first as it loads into the schema created
+// by the Mattermost migrations.
+var products = []string{"", "boards", "playbooks"}
+
+func productName(product string) string {
+	if product == "" {
+		return "mattermost"
+	}
+	return product
+}
+
+// productConfig is a rendered configuration of a product along with the
+// tables that it is going to load.
+type productConfig struct {
+	product string
+	config  string
+	filter  tableFilter
+	tables  []string
+}
+
+// GenerateAllProductsConfigurationFiles renders the configuration of every
+// product that has tables in the MySQL database into the output directory.
+// The files are prefixed with the order that they should be loaded.
+func GenerateAllProductsConfigurationFiles(outputDir string, config PgLoaderConfig, baseLogger *logger.Logger) error {
+	tables, err := mysqlTables(config.MySQLDSN)
+	if err != nil {
+		return err
+	}
+
+	configs, err := renderProductConfigs(config, tables, baseLogger)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(outputDir, 0750)
+	if err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	var order int
+	for _, c := range configs {
+		name := productName(c.product)
+		if c.product != "" && len(c.tables) == 0 {
+			baseLogger.Info("no tables in the source database, skipping", "product", name)
+			continue
+		}
+
+		order++
+		file := fmt.Sprintf("%02d-%s.load", order, name)
+		err = os.WriteFile(filepath.Join(outputDir, file), []byte(c.config), 0600)
+		if err != nil {
+			return fmt.Errorf("could not write configuration for %s: %w", name, err)
+		}
+
+		baseLogger.Info("configuration is written", "product", name, "file", file, "tables", strings.Join(c.tables, ", "))
+	}
+
+	report := evaluateCoverage(configs, tables)
+	if skipped := report.Skipped(); len(skipped) > 0 {
+		baseLogger.Info(fmt.Sprintf("%d tables are skipped on purpose", len(skipped)), "tables", strings.Join(coverageTableNames(skipped), ", "))
+	}
+
+	if uncovered := report.NeverLoaded(); len(uncovered) > 0 {
+		baseLogger.Warn(fmt.Sprintf("%d tables are not covered by any configuration", len(uncovered)), "tables", strings.Join(coverageTableNames(uncovered), ", "))
+	}
+
+	baseLogger.Info(fmt.Sprintf("%d configurations are generated, they should be loaded in order", order), "dir", outputDir)
+
+	return nil
+}
+
+// renderProductConfigs renders the configuration of each product and
+// evaluates the table filters against the given tables.
+func renderProductConfigs(config PgLoaderConfig, tables []string, baseLogger *logger.Logger) ([]productConfig, error) {
+	configs := make([]productConfig, 0, len(products))
+	for _, product := range products {
+		templ, params, err := prepareConfiguration(product, config, baseLogger)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		err = templ.Execute(&buf, params)
+		if err != nil {
+			return nil, fmt.Errorf("error during executing the template for %s: %w", productName(product), err)
+		}
+
+		filter, err := parseTableFilters(buf.String())
+		if err != nil {
+			return nil, fmt.Errorf("could not parse table filters of %s: %w", productName(product), err)
+		}
+
+		c := productConfig{
+			product: product,
+			config:  buf.String(),
+			filter:  filter,
+		}
+		for _, table := range tables {
+			if filter.includes(table) {
+				c.tables = append(c.tables, table)
+			}
+		}
+
+		configs = append(configs, c)
+	}
+
+	return configs, nil
+}
+
+func mysqlTables(dsn string) ([]string, error) {
+	mysqlDB, err := store.NewStore("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer mysqlDB.Close()
+
+	stats, err := mysqlDB.GetTableStats(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+
+	tables := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		tables = append(tables, stat.Name)
+	}
+
+	return tables, nil
+}
@@ -0,0 +1,205 @@
+package pgloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+const stateFile = "state.json"
+
+type RunOptions struct {
+	Dir         string
+	PostgresDSN string
+	Binary      string
+	Parallel    int
+}
+
+// runState keeps track of the completed steps of a split load, so that a
+// failed run can be continued from where it is left.
+type runState struct {
+	mu sync.Mutex
+
+	path      string
+	Completed []string `json:"completed"`
+}
+
+func loadRunState(dir string) (*runState, error) {
+	state := &runState{path: filepath.Join(dir, stateFile)}
+
+	b, err := os.ReadFile(state.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read state file: %w", err)
+	}
+
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse state file: %w", err)
+	}
+
+	return state, nil
+}
+
+func (s *runState) isCompleted(step string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.Completed {
+		if c == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *runState) complete(step string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Completed = append(s.Completed, step)
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal state: %w", err)
+	}
+
+	return os.WriteFile(s.path, b, 0600)
+}
+
+// RunSplitLoad runs the configurations generated by GenerateSplitConfigurationFiles.
+// The BEFORE LOAD statements run first, then the parts either sequentially or
+// in parallel, and finally the AFTER LOAD statements. Every completed step is
+// recorded, hence running it again only executes the remaining steps.
+func RunSplitLoad(opts RunOptions, baseLogger *logger.Logger) error {
+	b, err := os.ReadFile(filepath.Join(opts.Dir, manifestFile))
+	if err != nil {
+		return fmt.Errorf("could not read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	err = json.Unmarshal(b, &manifest)
+	if err != nil {
+		return fmt.Errorf("could not parse manifest: %w", err)
+	}
+
+	state, err := loadRunState(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err = exec.LookPath(opts.Binary); err != nil {
+		return fmt.Errorf("pgloader binary is not installed: %w", err)
+	}
+
+	postgresDB, err := store.NewStore("postgres", opts.PostgresDSN)
+	if err != nil {
+		return err
+	}
+	defer postgresDB.Close()
+
+	err = runLoadHook(postgresDB, opts.Dir, manifest.Before, state, baseLogger)
+	if err != nil {
+		return err
+	}
+
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var wg sync.WaitGroup
+	var failedMut sync.Mutex
+	var failed []string
+	sem := make(chan struct{}, parallel)
+
+	for _, part := range manifest.Parts {
+		if state.isCompleted(part.Name) {
+			baseLogger.Info("already loaded, skipping", "part", part.Name)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(part Part) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := runPart(opts, part, baseLogger)
+			if err == nil {
+				err = state.complete(part.Name)
+			}
+			if err != nil {
+				baseLogger.Error("could not load", "part", part.Name, "err", err)
+				failedMut.Lock()
+				failed = append(failed, part.Name)
+				failedMut.Unlock()
+			}
+		}(part)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d part(s) failed to load: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return runLoadHook(postgresDB, opts.Dir, manifest.After, state, baseLogger)
+}
+
+func runLoadHook(db *store.DB, dir, file string, state *runState, baseLogger *logger.Logger) error {
+	if state.isCompleted(file) {
+		baseLogger.Info("already executed, skipping", "file", file)
+		return nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	baseLogger.Info("executing", "file", file)
+	err = db.ExecQuery(context.TODO(), string(b))
+	if err != nil {
+		return fmt.Errorf("error while executing %s: %w", file, err)
+	}
+
+	return state.complete(file)
+}
+
+func runPart(opts RunOptions, part Part, baseLogger *logger.Logger) error {
+	logFile, err := os.Create(filepath.Join(opts.Dir, strings.TrimSuffix(part.File, filepath.Ext(part.File))+".log"))
+	if err != nil {
+		return fmt.Errorf("could not create log file: %w", err)
+	}
+	defer logFile.Close()
+
+	baseLogger.Info("loading", "part", part.Name)
+	start := time.Now()
+
+	cmd := exec.Command(opts.Binary, "--on-error-stop", part.File)
+	cmd.Dir = opts.Dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("pgloader failed, see %s for details: %w", logFile.Name(), err)
+	}
+
+	baseLogger.Info("loaded", "part", part.Name, "duration", time.Since(start).Round(time.Second))
+
+	return nil
+}
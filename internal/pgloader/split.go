@@ -0,0 +1,176 @@
+package pgloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/internal/store"
+)
+
+const (
+	manifestFile  = "manifest.json"
+	beforeLoadSQL = "before.sql"
+	afterLoadSQL  = "after.sql"
+
+	// restPart is the name of the part that loads every table which is not
+	// loaded by its own configuration.
+	restPart = "rest"
+)
+
+var loadHookStatement = regexp.MustCompile(`(?s)\$\$ (.*?) \$\$`)
+
+type SplitOptions struct {
+	OutputDir string
+	// LargeTableThreshold is the estimated number of rows a table should have
+	// to be loaded with its own configuration.
+	LargeTableThreshold int64
+}
+
+// Manifest describes the parts of a split load.
+type Manifest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Parts  []Part `json:"parts"`
+}
+
+// Part is a single pgloader configuration of a split load.
+type Part struct {
+	Name   string   `json:"name"`
+	File   string   `json:"file"`
+	Tables []string `json:"tables,omitempty"`
+}
+
+// GenerateSplitConfigurationFiles writes one configuration per large table and
+// a configuration for the remaining tables into the output directory. The
+// BEFORE LOAD and AFTER LOAD statements are written into separate files so
+// that they only run once for the whole load. The state of a previous load in
+// the output directory is removed, since it doesn't apply to the new parts.
+func GenerateSplitConfigurationFiles(opts SplitOptions, config PgLoaderConfig, baseLogger *logger.Logger) error {
+	templ, params, err := prepareConfiguration("", config, baseLogger)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = templ.Execute(&buf, params)
+	if err != nil {
+		return fmt.Errorf("error during executing the template: %w", err)
+	}
+
+	filter, err := parseTableFilters(buf.String())
+	if err != nil {
+		return fmt.Errorf("could not parse table filters: %w", err)
+	}
+
+	mysqlDB, err := store.NewStore("mysql", config.MySQLDSN)
+	if err != nil {
+		return err
+	}
+	defer mysqlDB.Close()
+
+	stats, err := mysqlDB.GetTableStats(context.TODO())
+	if err != nil {
+		return fmt.Errorf("could not get table stats: %w", err)
+	}
+
+	var largeTables []string
+	for _, stat := range stats {
+		if stat.Rows < opts.LargeTableThreshold || !filter.includes(stat.Name) {
+			continue
+		}
+		largeTables = append(largeTables, stat.Name)
+	}
+
+	err = os.MkdirAll(opts.OutputDir, 0750)
+	if err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	err = os.Remove(filepath.Join(opts.OutputDir, stateFile))
+	if err == nil {
+		baseLogger.Warn("the state of the previous load is removed, the load will start over", "file", stateFile)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove the state of the previous load: %w", err)
+	}
+
+	manifest := Manifest{
+		Before: beforeLoadSQL,
+		After:  afterLoadSQL,
+	}
+
+	params.Truncate = true
+	params.SkipLoadHooks = true
+
+	for _, table := range largeTables {
+		manifest.Parts = append(manifest.Parts, Part{
+			Name:   table,
+			File:   fmt.Sprintf("%02d-%s.load", len(manifest.Parts)+1, strings.ToLower(table)),
+			Tables: []string{table},
+		})
+	}
+	manifest.Parts = append(manifest.Parts, Part{
+		Name: restPart,
+		File: fmt.Sprintf("%02d-%s.load", len(manifest.Parts)+1, restPart),
+	})
+
+	for _, part := range manifest.Parts {
+		p := params
+		if part.Name == restPart {
+			p.ExcludeTables = largeTables
+		} else {
+			p.IncludeTables = part.Tables
+		}
+
+		buf.Reset()
+		err = templ.Execute(&buf, p)
+		if err != nil {
+			return fmt.Errorf("error during executing the template for %s: %w", part.Name, err)
+		}
+
+		err = os.WriteFile(filepath.Join(opts.OutputDir, part.File), buf.Bytes(), 0600)
+		if err != nil {
+			return fmt.Errorf("could not write configuration for %s: %w", part.Name, err)
+		}
+		baseLogger.Info("configuration is written", "part", part.Name, "file", part.File)
+	}
+
+	for file, hook := range map[string]string{beforeLoadSQL: "beforeLoad", afterLoadSQL: "afterLoad"} {
+		buf.Reset()
+		err = templ.ExecuteTemplate(&buf, hook, params)
+		if err != nil {
+			return fmt.Errorf("error during executing the template for %s: %w", hook, err)
+		}
+
+		var statements []string
+		for _, m := range loadHookStatement.FindAllStringSubmatch(buf.String(), -1) {
+			statements = append(statements, m[1])
+		}
+
+		err = os.WriteFile(filepath.Join(opts.OutputDir, file), []byte(strings.Join(statements, "\n")+"\n"), 0600)
+		if err != nil {
+			return fmt.Errorf("could not write %s: %w", file, err)
+		}
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal manifest: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(opts.OutputDir, manifestFile), b, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	baseLogger.Info(fmt.Sprintf("%d configurations are generated", len(manifest.Parts)), "dir", opts.OutputDir)
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package pgloader
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/blang/semver/v4"
+)
+
+// MinVersion is the earliest pgloader version that the configurations are
+// known to work with.
+var MinVersion = semver.MustParse("3.6.9")
+
+var versionRegex = regexp.MustCompile(`version "?(\d+\.\d+(?:\.\d+)?)`)
+
+// Version runs the pgloader binary to read its version, e.g. the output
+// starts with `pgloader version "3.6.9"`.
+func Version(binary string) (semver.Version, error) {
+	out, err := exec.Command(binary, "--version").Output()
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("could not run pgloader: %w", err)
+	}
+
+	match := versionRegex.FindSubmatch(out)
+	if match == nil {
+		return semver.Version{}, fmt.Errorf("could not find the version in %q", out)
+	}
+
+	v, err := semver.ParseTolerant(string(match[1]))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("could not parse version %q: %w", match[1], err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Stderr is the console that the logs and the live status line share.
+var Stderr = NewConsole(os.Stderr)
+
+// Console serializes the writes to a terminal and keeps a live status line
+// below the written lines.
+type Console struct {
+	mu     sync.Mutex
+	w      io.Writer
+	status string
+}
+
+func NewConsole(w io.Writer) *Console {
+	return &Console{w: w}
+}
+
+// Write writes p above the status line.
+func (c *Console) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clear()
+	n, err := c.w.Write(p)
+	c.draw()
+
+	return n, err
+}
+
+// SetStatus replaces the status line.
+func (c *Console) SetStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clear()
+	c.status = status
+	c.draw()
+}
+
+// ClearStatus removes the status line.
+func (c *Console) ClearStatus() {
+	c.SetStatus("")
+}
+
+func (c *Console) clear() {
+	if c.status != "" {
+		_, _ = io.WriteString(c.w, "\r\033[K")
+	}
+}
+
+func (c *Console) draw() {
+	if c.status != "" {
+		_, _ = io.WriteString(c.w, c.status)
+	}
+}
+
+// IsTerminal reports whether the file is a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,199 @@
+package progress
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/isacikgoz/migration-assist/internal/logger"
+)
+
+// Task is a unit of work to be tracked. The weight is used to estimate the
+// remaining time, e.g. the number of rows that the task processes.
+type Task struct {
+	Name   string
+	Weight int64
+}
+
+type Options struct {
+	// Console renders a live status line if it's set, otherwise the progress
+	// is logged periodically.
+	Console *Console
+	// Interval is the interval of the log lines, it's ignored for the live
+	// status line which is refreshed every second.
+	Interval time.Duration
+}
+
+// Tracker reports the progress of a sequence of tasks with the elapsed time
+// and an ETA estimated from the weights of the completed tasks.
+type Tracker struct {
+	mu sync.Mutex
+
+	logger  *logger.Logger
+	console *Console
+	weights map[string]int64
+	total   int
+	weight  int64
+
+	start        time.Time
+	current      string
+	currentStart time.Time
+	completed    int
+	doneWeight   int64
+	doneTime     time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTracker starts tracking the tasks. Stop should be called once the tasks
+// are completed.
+func NewTracker(tasks []Task, baseLogger *logger.Logger, opts Options) *Tracker {
+	t := &Tracker{
+		logger:  baseLogger,
+		console: opts.Console,
+		weights: make(map[string]int64, len(tasks)),
+		total:   len(tasks),
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+	}
+
+	for _, task := range tasks {
+		// every task takes some time regardless of its weight
+		w := task.Weight + 1
+		t.weights[task.Name] = w
+		t.weight += w
+	}
+
+	interval := opts.Interval
+	if t.console != nil {
+		interval = time.Second
+	}
+
+	if interval > 0 {
+		t.wg.Add(1)
+		go t.run(interval)
+	}
+
+	return t
+}
+
+// Start marks the task as the current one. A nil tracker ignores the tasks.
+func (t *Tracker) Start(name string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.current = name
+	t.currentStart = time.Now()
+	t.mu.Unlock()
+
+	t.refresh()
+}
+
+// Done marks the current task as completed.
+func (t *Tracker) Done() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if t.current != "" {
+		t.completed++
+		t.doneWeight += t.weights[t.current]
+		t.doneTime += time.Since(t.currentStart)
+		t.current = ""
+	}
+	t.mu.Unlock()
+
+	t.refresh()
+}
+
+// Stop stops reporting the progress.
+func (t *Tracker) Stop() {
+	close(t.stop)
+	t.wg.Wait()
+
+	if t.console != nil {
+		t.console.ClearStatus()
+	}
+}
+
+func (t *Tracker) run(interval time.Duration) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			if t.console != nil {
+				t.refresh()
+				continue
+			}
+
+			t.mu.Lock()
+			current, completed, elapsed, eta := t.current, t.completed, time.Since(t.start), t.eta()
+			t.mu.Unlock()
+
+			t.logger.Info("progress", "current", current, "completed", completed, "total", t.total, "elapsed", elapsed.Round(time.Second), "eta", formatETA(eta))
+		}
+	}
+}
+
+func (t *Tracker) refresh() {
+	if t.console == nil {
+		return
+	}
+
+	t.mu.Lock()
+	status := fmt.Sprintf("[%d/%d] %s elapsed, ETA %s", t.completed, t.total, formatDuration(time.Since(t.start)), formatETA(t.eta()))
+	if t.current != "" {
+		status += fmt.Sprintf(" | %s (%s)", t.current, formatDuration(time.Since(t.currentStart)))
+	}
+	t.mu.Unlock()
+
+	t.console.SetStatus(status)
+}
+
+// eta estimates the remaining time with the throughput of the completed
+// tasks, it's negative if it can't be estimated yet.
+func (t *Tracker) eta() time.Duration {
+	if t.doneWeight == 0 || t.doneTime == 0 {
+		return -1
+	}
+
+	rate := float64(t.doneWeight) / float64(t.doneTime)
+	remaining := time.Duration(float64(t.weight-t.doneWeight) / rate)
+	if t.current != "" {
+		remaining -= time.Since(t.currentStart)
+	}
+
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+func formatETA(d time.Duration) string {
+	if d < 0 {
+		return "--:--:--"
+	}
+
+	return formatDuration(d)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, d/time.Second)
+}
@@ -0,0 +1,102 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerETA(t *testing.T) {
+	tests := []struct {
+		name       string
+		weight     int64
+		doneWeight int64
+		doneTime   time.Duration
+		expected   time.Duration
+	}{
+		{
+			name:     "nothing is completed",
+			weight:   100,
+			expected: -1,
+		},
+		{
+			name:       "completed in no time",
+			weight:     100,
+			doneWeight: 10,
+			expected:   -1,
+		},
+		{
+			name:       "a quarter is completed",
+			weight:     100,
+			doneWeight: 25,
+			doneTime:   10 * time.Second,
+			expected:   30 * time.Second,
+		},
+		{
+			name:       "everything is completed",
+			weight:     100,
+			doneWeight: 100,
+			doneTime:   time.Minute,
+			expected:   0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := &Tracker{weight: tc.weight, doneWeight: tc.doneWeight, doneTime: tc.doneTime}
+			if got := tracker.eta(); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTrackerETAWithCurrentTask(t *testing.T) {
+	tracker := NewTracker([]Task{{Name: "a", Weight: 9}, {Name: "b", Weight: 9}, {Name: "c", Weight: 19}}, nil, Options{})
+	defer tracker.Stop()
+
+	if got := tracker.eta(); got != -1 {
+		t.Fatalf("expected no estimate before a task is completed, got %s", got)
+	}
+
+	tracker.Start("a")
+	tracker.mu.Lock()
+	tracker.currentStart = time.Now().Add(-10 * time.Second)
+	tracker.mu.Unlock()
+	tracker.Done()
+
+	// the remaining weight is three times the completed one
+	eta := tracker.eta()
+	if eta < 29*time.Second || eta > 31*time.Second {
+		t.Fatalf("expected an estimate of 30s, got %s", eta)
+	}
+
+	// the elapsed time of the current task is deducted from the estimate
+	tracker.Start("b")
+	tracker.mu.Lock()
+	tracker.currentStart = time.Now().Add(-5 * time.Second)
+	tracker.mu.Unlock()
+
+	eta = tracker.eta()
+	if eta < 24*time.Second || eta > 26*time.Second {
+		t.Fatalf("expected an estimate of 25s, got %s", eta)
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		eta      time.Duration
+		expected string
+	}{
+		{eta: -1, expected: "--:--:--"},
+		{eta: 0, expected: "00:00:00"},
+		{eta: 90*time.Minute + 1500*time.Millisecond, expected: "01:30:02"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expected, func(t *testing.T) {
+			if got := formatETA(tc.eta); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/isacikgoz/migration-assist/internal/audit"
+	"github.com/isacikgoz/migration-assist/internal/logger"
+)
+
+var (
+	auditLog    *audit.Log
+	auditLogger *logger.Logger
+)
+
+// SetAuditLog sets the log that every statement executed by the stores is
+// recorded to, the statements are not recorded if it's nil. A record that
+// can't be written doesn't fail the statement, it's reported to the logger.
+func SetAuditLog(l *audit.Log, logger *logger.Logger) {
+	auditLog = l
+	auditLogger = logger
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execContext executes the statement on the connection and records it to the
+// audit log.
+func (db *DB) execContext(ctx context.Context, conn execer, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := conn.ExecContext(ctx, query, args...)
+
+	rows := int64(-1)
+	if err == nil {
+		if n, err2 := res.RowsAffected(); err2 == nil {
+			rows = n
+		}
+	}
+
+	db.audit(audit.KindExec, query, args, rows, start, err)
+
+	return res, err
+}
+
+// queryContext runs the query on the connection and records it to the audit
+// log, the number of rows is unknown since they are read by the caller.
+func (db *DB) queryContext(ctx context.Context, conn queryer, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query, args...)
+
+	db.audit(audit.KindSelect, query, args, -1, start, err)
+
+	return rows, err
+}
+
+// auditedRow is a row that is recorded to the audit log once it's scanned.
+type auditedRow struct {
+	db    *DB
+	row   *sql.Row
+	query string
+	args  []any
+	start time.Time
+}
+
+// queryRowContext runs the query on the connection, the query is recorded to
+// the audit log when the row is scanned.
+func (db *DB) queryRowContext(ctx context.Context, conn queryer, query string, args ...any) *auditedRow {
+	start := time.Now()
+
+	return &auditedRow{
+		db:    db,
+		row:   conn.QueryRowContext(ctx, query, args...),
+		query: query,
+		args:  args,
+		start: start,
+	}
+}
+
+func (r *auditedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+
+	switch {
+	case err == nil:
+		r.db.audit(audit.KindSelect, r.query, r.args, 1, r.start, nil)
+	case errors.Is(err, sql.ErrNoRows):
+		r.db.audit(audit.KindSelect, r.query, r.args, 0, r.start, nil)
+	default:
+		r.db.audit(audit.KindSelect, r.query, r.args, -1, r.start, err)
+	}
+
+	return err
+}
+
+func (db *DB) audit(kind, query string, args []any, rows int64, start time.Time, err error) {
+	if auditLog == nil {
+		return
+	}
+
+	r := audit.Record{
+		Time:      start,
+		DBType:    db.dbType,
+		Database:  db.databaseName,
+		Kind:      kind,
+		Statement: query,
+		Params:    args,
+		Rows:      rows,
+		Duration:  time.Since(start),
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if err != nil {
+		r.Outcome = audit.OutcomeError
+		r.Error = err.Error()
+	}
+
+	if err := auditLog.Write(r); err != nil && auditLogger != nil {
+		auditLogger.Warn("could not record the statement to the audit log", "err", err)
+	}
+}
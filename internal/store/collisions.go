@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The kinds of the key collisions.
+const (
+	// CollisionDuplicate is a key that is shared by multiple rows byte for
+	// byte, which violates the unique index while loading into Postgres.
+	CollisionDuplicate = "duplicate"
+	// CollisionDiverged is a key that is equal under the MySQL collation but
+	// is spelled differently, e.g. by case or trailing spaces. The rows are
+	// no longer equal in Postgres.
+	CollisionDiverged = "diverged"
+)
+
+const postgresUniqueIndexesQuery = `SELECT t.relname, i.relname, ix.indisprimary, ix.indpred IS NOT NULL, ix.indexprs IS NOT NULL,
+	COALESCE(array_to_string(ARRAY(
+		SELECT a.attname
+		FROM unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		ORDER BY k.ord
+	), ','), '')
+FROM pg_catalog.pg_index ix
+JOIN pg_catalog.pg_class t ON t.oid = ix.indrelid
+JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+WHERE n.nspname = $1 AND ix.indisunique
+ORDER BY t.relname, i.relname`
+
+// UniqueIndex is a unique index or a primary key of a Postgres table.
+type UniqueIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+	Primary bool
+	// Partial indexes have a predicate, and the expression indexes have
+	// expressions in place of some of the columns.
+	Partial    bool
+	Expression bool
+}
+
+// GetUniqueIndexes returns the unique indexes of the tables in the schema.
+func (db *DB) GetUniqueIndexes(ctx context.Context, schema string) ([]UniqueIndex, error) {
+	if db.dbType != "postgres" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, postgresUniqueIndexesQuery, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not query unique indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []UniqueIndex
+	for rows.Next() {
+		var idx UniqueIndex
+		var columns string
+		if err := rows.Scan(&idx.Table, &idx.Name, &idx.Primary, &idx.Partial, &idx.Expression, &columns); err != nil {
+			return nil, fmt.Errorf("could not scan index: %w", err)
+		}
+		if columns != "" {
+			idx.Columns = strings.Split(columns, ",")
+		}
+		indexes = append(indexes, idx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return indexes, nil
+}
+
+// KeyCollisionGroup is a set of rows that share a key. The key lists the
+// spellings of the key for the diverged collisions.
+type KeyCollisionGroup struct {
+	Key  string
+	Rows int
+}
+
+// KeyCollisions are the groups of a kind of collision within a table, the
+// groups are limited while the total is not.
+type KeyCollisions struct {
+	Kind   string
+	Total  int
+	Groups []KeyCollisionGroup
+}
+
+// FindKeyCollisions looks for the rows of the MySQL table that collide under
+// Postgres semantics, or that would no longer collide, on the given columns.
+// The rows with a null in any of the columns are not considered, since they
+// never collide in either database.
+func (db *DB) FindKeyCollisions(ctx context.Context, table string, columns []string, kind string, limit int) (*KeyCollisions, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	quoted := make([]string, len(columns))
+	binary := make([]string, len(columns))
+	notNull := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteMySQLIdentifier(c)
+		binary[i] = "BINARY " + quoted[i]
+		notNull[i] = quoted[i] + " IS NOT NULL"
+	}
+	key := fmt.Sprintf("CONCAT_WS(',', %s)", strings.Join(quoted, ", "))
+
+	var group, having, display string
+	switch kind {
+	case CollisionDuplicate:
+		group = strings.Join(binary, ", ")
+		having = "COUNT(*) > 1"
+		display = fmt.Sprintf("MIN(%s)", key)
+	case CollisionDiverged:
+		group = strings.Join(quoted, ", ")
+		having = fmt.Sprintf("COUNT(DISTINCT %s) > 1", strings.Join(binary, ", "))
+		display = fmt.Sprintf("GROUP_CONCAT(DISTINCT %s SEPARATOR ' | ')", key)
+	default:
+		return nil, fmt.Errorf("unsupported collision kind: %s", kind)
+	}
+
+	from := fmt.Sprintf("FROM %s WHERE %s GROUP BY %s HAVING %s", quoteMySQLIdentifier(table), strings.Join(notNull, " AND "), group, having)
+
+	total, err := db.RunSelectCountQuery(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 %s) g", from))
+	if err != nil {
+		return nil, fmt.Errorf("could not count %s keys: %w", kind, err)
+	}
+
+	collisions := &KeyCollisions{Kind: kind, Total: total}
+	if total == 0 || limit <= 0 {
+		return collisions, nil
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, fmt.Sprintf("SELECT %s, COUNT(*) %s LIMIT %d", display, from, limit))
+	if err != nil {
+		return nil, fmt.Errorf("could not query %s keys: %w", kind, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g KeyCollisionGroup
+		if err := rows.Scan(&g.Key, &g.Rows); err != nil {
+			return nil, fmt.Errorf("could not scan key: %w", err)
+		}
+		collisions.Groups = append(collisions.Groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return collisions, nil
+}
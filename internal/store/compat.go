@@ -0,0 +1,365 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// The kinds of the compatibility checks.
+const (
+	CompatZeroDate      = "zero-date"
+	CompatInvalidUTF8   = "invalid-utf8"
+	CompatCaseCollision = "case-collision"
+	CompatTrailingSpace = "trailing-space"
+	CompatIntRange      = "int-range"
+	CompatBoolean       = "boolean"
+	CompatInvalidJSON   = "invalid-json"
+)
+
+const mysqlCompatColumnsQuery = `SELECT c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.COLUMN_TYPE, COALESCE(c.CHARACTER_SET_NAME, ''), COALESCE(c.COLLATION_NAME, '')
+FROM information_schema.COLUMNS c
+JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`
+
+// mysqlUniqueColumnsQuery returns the columns that are unique by themselves,
+// the composite keys are not considered.
+const mysqlUniqueColumnsQuery = `SELECT TABLE_NAME, MAX(COLUMN_NAME)
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND NON_UNIQUE = 0
+GROUP BY TABLE_NAME, INDEX_NAME
+HAVING COUNT(*) = 1`
+
+// mysqlCollationsQuery returns the pad attributes of the collations, the
+// column doesn't exist before MySQL 8.0 where every collation is PAD SPACE.
+const mysqlCollationsQuery = `SELECT COLLATION_NAME, PAD_ATTRIBUTE FROM information_schema.COLLATIONS`
+
+// errBadField is the MySQL error of an unknown column.
+const errBadField = 1054
+
+const mysqlPrimaryKeysQuery = `SELECT TABLE_NAME, COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = DATABASE() AND CONSTRAINT_NAME = 'PRIMARY'
+ORDER BY TABLE_NAME, ORDINAL_POSITION`
+
+// CompatCheck is a scan of a MySQL column for the values that pgloader or
+// Postgres would reject or change.
+type CompatCheck struct {
+	Kind   string
+	Table  string
+	Column string
+
+	condition  string
+	primaryKey []string
+}
+
+// PrimaryKey returns the primary key columns of the table.
+func (c CompatCheck) PrimaryKey() []string {
+	return c.primaryKey
+}
+
+func (c CompatCheck) String() string {
+	return fmt.Sprintf("%s.%s/%s", strings.ToLower(c.Table), strings.ToLower(c.Column), c.Kind)
+}
+
+// CompatFinding is the result of a compatibility check. The sample keys are
+// the primary keys of some of the offending rows.
+type CompatFinding struct {
+	CompatCheck
+	Count      int
+	SampleKeys []string
+}
+
+// integerRange is the range of an integer type, the maximum doesn't fit into
+// an int64 for the unsigned bigint of MySQL.
+type integerRange struct {
+	min int64
+	max uint64
+}
+
+var postgresIntegerRanges = map[string]integerRange{
+	"smallint": {math.MinInt16, math.MaxInt16},
+	"integer":  {math.MinInt32, math.MaxInt32},
+	"bigint":   {math.MinInt64, math.MaxInt64},
+}
+
+func mysqlIntegerRange(dataType string, unsigned bool) (integerRange, bool) {
+	var bits uint
+	switch dataType {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int":
+		bits = 32
+	case "bigint":
+		bits = 64
+	default:
+		return integerRange{}, false
+	}
+
+	if unsigned {
+		return integerRange{0, math.MaxUint64 >> (64 - bits)}, true
+	}
+
+	return integerRange{-1 << (bits - 1), 1<<(bits-1) - 1}, true
+}
+
+// intRangeCondition returns the condition of the values of the MySQL integer
+// type that are out of the range of the Postgres type, it's empty if every
+// value fits.
+func intRangeCondition(col, dataType, columnType, targetType string) string {
+	src, ok := mysqlIntegerRange(dataType, strings.Contains(columnType, "unsigned"))
+	if !ok {
+		return ""
+	}
+
+	dst, ok := postgresIntegerRanges[targetType]
+	if !ok {
+		return ""
+	}
+
+	var conditions []string
+	if src.max > dst.max {
+		conditions = append(conditions, fmt.Sprintf("%s > %d", col, dst.max))
+	}
+	if src.min < dst.min {
+		conditions = append(conditions, fmt.Sprintf("%s < %d", col, dst.min))
+	}
+
+	return strings.Join(conditions, " OR ")
+}
+
+// caseSensitiveCollation reports whether the collation compares the case of
+// the strings, the unique indexes of the other collations already reject the
+// values that only differ by case.
+func caseSensitiveCollation(collation string) bool {
+	return strings.HasSuffix(collation, "_bin") || strings.HasSuffix(collation, "_cs")
+}
+
+// GetCompatChecks returns the compatibility checks for the columns of the
+// MySQL database:
+//   - zero dates, which can't be represented in Postgres
+//   - invalid UTF-8 byte sequences in the utf8 columns
+//   - unique values of the case sensitive columns that only differ by case,
+//     which collide if they are compared case insensitively
+//   - unique values that collide once their trailing spaces are trimmed,
+//     which the NO PAD collations of MySQL allow
+//   - integers that overflow the types of the target columns
+//   - tinyint(1) values other than 0 and 1, which are cast to booleans
+//
+// The target columns are the columns of the Postgres schema that the data is
+// loaded into, the integer ranges are not checked if they are not supplied.
+func (db *DB) GetCompatChecks(ctx context.Context, target []Column) ([]CompatCheck, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	primaryKeys, err := db.mysqlPrimaryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]bool)
+	err = db.queryPairs(ctx, mysqlUniqueColumnsQuery, func(table, column string) {
+		unique[table+"."+column] = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not query unique keys: %w", err)
+	}
+
+	noPad, err := db.mysqlNoPadCollations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTypes := make(map[string]string, len(target))
+	for _, c := range target {
+		targetTypes[strings.ToLower(c.Table)+"."+strings.ToLower(c.Name)] = c.DataType
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, mysqlCompatColumnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("could not query columns: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []CompatCheck
+	for rows.Next() {
+		var table, column, dataType, columnType, charset, collation string
+		if err := rows.Scan(&table, &column, &dataType, &columnType, &charset, &collation); err != nil {
+			return nil, fmt.Errorf("could not scan column: %w", err)
+		}
+
+		add := func(kind, condition string) {
+			checks = append(checks, CompatCheck{
+				Kind:       kind,
+				Table:      table,
+				Column:     column,
+				condition:  condition,
+				primaryKey: primaryKeys[table],
+			})
+		}
+
+		col := quoteMySQLIdentifier(column)
+		isKey := unique[table+"."+column]
+
+		switch dataType {
+		case "date", "datetime", "timestamp":
+			add(CompatZeroDate, fmt.Sprintf("MONTH(%[1]s) = 0 OR DAYOFMONTH(%[1]s) = 0", col))
+		case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+			if strings.HasPrefix(charset, "utf8") {
+				// the invalid sequences are replaced while converting the raw bytes
+				add(CompatInvalidUTF8, fmt.Sprintf("CAST(CONVERT(CAST(%[1]s AS BINARY) USING utf8mb4) AS BINARY) <> CAST(%[1]s AS BINARY)", col))
+			}
+			if isKey && caseSensitiveCollation(collation) {
+				add(CompatCaseCollision, fmt.Sprintf("LOWER(%[1]s) IN (SELECT LOWER(%[1]s) FROM %[2]s GROUP BY LOWER(%[1]s) HAVING COUNT(*) > 1)", col, quoteMySQLIdentifier(table)))
+			}
+			// the PAD SPACE collations ignore the trailing spaces, hence the
+			// unique index already rejects these collisions
+			if isKey && noPad[collation] {
+				add(CompatTrailingSpace, fmt.Sprintf("TRIM(TRAILING ' ' FROM %[1]s) IN (SELECT TRIM(TRAILING ' ' FROM %[1]s) FROM %[2]s GROUP BY TRIM(TRAILING ' ' FROM %[1]s) HAVING COUNT(*) > 1)", col, quoteMySQLIdentifier(table)))
+			}
+		case "tinyint", "smallint", "mediumint", "int", "bigint":
+			if strings.HasPrefix(columnType, "tinyint(1)") {
+				add(CompatBoolean, fmt.Sprintf("%s NOT IN (0, 1)", col))
+			} else if condition := intRangeCondition(col, dataType, columnType, targetTypes[strings.ToLower(table)+"."+strings.ToLower(column)]); condition != "" {
+				add(CompatIntRange, condition)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return checks, nil
+}
+
+// RunCompatCheck counts the offending rows of the check and collects the
+// primary keys of up to sampleSize of them.
+func (db *DB) RunCompatCheck(ctx context.Context, check CompatCheck, sampleSize int) (*CompatFinding, error) {
+	table := quoteMySQLIdentifier(check.Table)
+
+	count, err := db.RunSelectCountQuery(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, check.condition))
+	if err != nil {
+		return nil, fmt.Errorf("could not run %s check: %w", check.Kind, err)
+	}
+
+	finding := &CompatFinding{CompatCheck: check, Count: count}
+	if count == 0 || sampleSize <= 0 || len(check.primaryKey) == 0 {
+		return finding, nil
+	}
+
+	keys := make([]string, len(check.primaryKey))
+	for i, k := range check.primaryKey {
+		keys[i] = quoteMySQLIdentifier(k)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, fmt.Sprintf("SELECT CONCAT_WS(',', %s) FROM %s WHERE %s LIMIT %d", strings.Join(keys, ", "), table, check.condition, sampleSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not sample %s check: %w", check.Kind, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("could not scan key: %w", err)
+		}
+		finding.SampleKeys = append(finding.SampleKeys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return finding, nil
+}
+
+// GetJSONChecks returns the checks that validate the JSON values of the
+// given MySQL columns.
+func (db *DB) GetJSONChecks(ctx context.Context, columns []Column) ([]CompatCheck, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	primaryKeys, err := db.mysqlPrimaryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]CompatCheck, 0, len(columns))
+	for _, c := range columns {
+		checks = append(checks, CompatCheck{
+			Kind:       CompatInvalidJSON,
+			Table:      c.Table,
+			Column:     c.Name,
+			condition:  fmt.Sprintf("%[1]s IS NOT NULL AND JSON_VALID(%[1]s) = 0", quoteMySQLIdentifier(c.Name)),
+			primaryKey: primaryKeys[c.Table],
+		})
+	}
+
+	return checks, nil
+}
+
+// mysqlNoPadCollations returns the collations that compare the trailing
+// spaces of the strings.
+func (db *DB) mysqlNoPadCollations(ctx context.Context) (map[string]bool, error) {
+	noPad := make(map[string]bool)
+	err := db.queryPairs(ctx, mysqlCollationsQuery, func(collation, padAttribute string) {
+		if padAttribute == "NO PAD" {
+			noPad[collation] = true
+		}
+	})
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errBadField {
+		return noPad, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not query collations: %w", err)
+	}
+
+	return noPad, nil
+}
+
+func (db *DB) mysqlPrimaryKeys(ctx context.Context) (map[string][]string, error) {
+	primaryKeys := make(map[string][]string)
+	err := db.queryPairs(ctx, mysqlPrimaryKeysQuery, func(table, column string) {
+		primaryKeys[table] = append(primaryKeys[table], column)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not query primary keys: %w", err)
+	}
+
+	return primaryKeys, nil
+}
+
+func (db *DB) queryPairs(ctx context.Context, query string, fn func(a, b string)) error {
+	rows, err := db.queryContext(ctx, db.conn, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a, b string
+		if err := rows.Scan(&a, &b); err != nil {
+			return err
+		}
+		fn(a, b)
+	}
+
+	return rows.Err()
+}
+
+func quoteMySQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
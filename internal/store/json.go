@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The empty values that the unrepairable JSON values are reset to.
+const (
+	emptyJSONObject = "{}"
+	emptyJSONArray  = "[]"
+)
+
+// jsonShapeSampleSize is the number of valid values that the shape of a JSON
+// column is determined from.
+const jsonShapeSampleSize = 1000
+
+// InvalidJSONRow is a row with an invalid JSON value. Key holds the values of
+// the primary key columns in order.
+type InvalidJSONRow struct {
+	Key   []string
+	Value string
+}
+
+// GetInvalidJSONRows returns the rows that fail the JSON check. The check
+// should be created with GetJSONChecks.
+func (db *DB) GetInvalidJSONRows(ctx context.Context, check CompatCheck) ([]InvalidJSONRow, error) {
+	if len(check.primaryKey) == 0 {
+		return nil, fmt.Errorf("%s has no primary key", check.Table)
+	}
+
+	columns := make([]string, 0, len(check.primaryKey)+1)
+	for _, k := range check.primaryKey {
+		columns = append(columns, quoteMySQLIdentifier(k))
+	}
+	columns = append(columns, quoteMySQLIdentifier(check.Column))
+
+	rows, err := db.queryContext(ctx, db.conn, fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), quoteMySQLIdentifier(check.Table), check.condition))
+	if err != nil {
+		return nil, fmt.Errorf("could not query invalid json: %w", err)
+	}
+	defer rows.Close()
+
+	var invalid []InvalidJSONRow
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+
+		row := InvalidJSONRow{Value: values[len(values)-1].String}
+		for _, v := range values[:len(values)-1] {
+			row.Key = append(row.Key, v.String)
+		}
+		invalid = append(invalid, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return invalid, nil
+}
+
+// UpdateJSONValue sets the value of the column of the row that is identified
+// by the primary key.
+func (db *DB) UpdateJSONValue(ctx context.Context, check CompatCheck, key []string, value string) error {
+	if len(key) != len(check.primaryKey) {
+		return fmt.Errorf("the key of %s should have %d values", check.Table, len(check.primaryKey))
+	}
+
+	conditions := make([]string, len(check.primaryKey))
+	args := []any{value}
+	for i, k := range check.primaryKey {
+		conditions[i] = quoteMySQLIdentifier(k) + " = ?"
+		args = append(args, key[i])
+	}
+
+	return db.ExecQuery(ctx, fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s", quoteMySQLIdentifier(check.Table), quoteMySQLIdentifier(check.Column), strings.Join(conditions, " AND ")), args...)
+}
+
+// GetEmptyJSONValue returns the empty value of the shape that the valid
+// values of the column have, i.e. an empty array if they are mostly arrays
+// and an empty object otherwise.
+func (db *DB) GetEmptyJSONValue(ctx context.Context, check CompatCheck) (string, error) {
+	col := quoteMySQLIdentifier(check.Column)
+
+	var arrays, total int
+	err := db.queryRowContext(ctx, db.conn, fmt.Sprintf("SELECT COALESCE(SUM(JSON_TYPE(v) = 'ARRAY'), 0), COUNT(*) FROM (SELECT %[1]s AS v FROM %[2]s WHERE %[1]s IS NOT NULL AND JSON_VALID(%[1]s) = 1 LIMIT %[3]d) s", col, quoteMySQLIdentifier(check.Table), jsonShapeSampleSize)).Scan(&arrays, &total)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the shape of %s.%s: %w", check.Table, check.Column, err)
+	}
+
+	if total > 0 && arrays*2 > total {
+		return emptyJSONArray, nil
+	}
+
+	return emptyJSONObject, nil
+}
+
+// RepairJSON fixes the trivially broken JSON values, i.e. the surrounding
+// whitespace, byte order marks, null characters and trailing commas. The
+// values that can't be repaired are reset to the given empty value.
+func RepairJSON(value, empty string) (string, bool) {
+	repaired := strings.TrimPrefix(strings.TrimSpace(value), "\ufeff")
+	repaired = strings.ReplaceAll(repaired, "\x00", "")
+	repaired = stripTrailingCommas(repaired)
+
+	if repaired != "" && json.Valid([]byte(repaired)) {
+		return repaired, true
+	}
+
+	return empty, false
+}
+
+// stripTrailingCommas removes the commas that are followed by the end of an
+// object or an array, the commas within the strings are kept.
+func stripTrailingCommas(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+
+	var inString, escaped bool
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && c == ',':
+			next := strings.TrimLeft(value[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		empty    string
+		expected string
+		repaired bool
+	}{
+		{
+			name:     "valid value",
+			value:    `{"a":1}`,
+			empty:    emptyJSONObject,
+			expected: `{"a":1}`,
+			repaired: true,
+		},
+		{
+			name:     "surrounding whitespace",
+			value:    " \n{\"a\":1}\t ",
+			empty:    emptyJSONObject,
+			expected: `{"a":1}`,
+			repaired: true,
+		},
+		{
+			name:     "byte order mark",
+			value:    "\ufeff{\"a\":1}",
+			empty:    emptyJSONObject,
+			expected: `{"a":1}`,
+			repaired: true,
+		},
+		{
+			name:     "null characters",
+			value:    "{\"a\":\x00\"b\"}\x00",
+			empty:    emptyJSONObject,
+			expected: `{"a":"b"}`,
+			repaired: true,
+		},
+		{
+			name:     "trailing commas",
+			value:    `{"a":[1,2,],"b":{"c":true,},}`,
+			empty:    emptyJSONObject,
+			expected: `{"a":[1,2],"b":{"c":true}}`,
+			repaired: true,
+		},
+		{
+			name:     "comma within a string",
+			value:    `{"a":"x,}","b":"y, ]",}`,
+			empty:    emptyJSONObject,
+			expected: `{"a":"x,}","b":"y, ]"}`,
+			repaired: true,
+		},
+		{
+			name:     "escaped quote within a string",
+			value:    `["a\",]",]`,
+			empty:    emptyJSONArray,
+			expected: `["a\",]"]`,
+			repaired: true,
+		},
+		{
+			name:     "broken object",
+			value:    `{"a":`,
+			empty:    emptyJSONObject,
+			expected: emptyJSONObject,
+		},
+		{
+			name:     "broken array",
+			value:    `[1, 2`,
+			empty:    emptyJSONArray,
+			expected: emptyJSONArray,
+		},
+		{
+			name:     "empty value",
+			value:    "  ",
+			empty:    emptyJSONObject,
+			expected: emptyJSONObject,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, repaired := RepairJSON(tc.value, tc.empty)
+			if repaired != tc.repaired {
+				t.Errorf("expected repaired to be %t, got %t", tc.repaired, repaired)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/isacikgoz/migration-assist/internal/git"
 	"github.com/isacikgoz/migration-assist/internal/logger"
 )
 
+const mysqlColumnsQuery = `SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_TYPE
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE())
+ORDER BY TABLE_NAME, ORDINAL_POSITION`
+
 type CreateTable struct {
 	Table       string
 	CreateTable string
@@ -69,14 +75,14 @@ func appendMultipleStatementsFlag(dataSource string) (string, error) {
 	return config.FormatDSN(), nil
 }
 
-func CompareMySQL(a, b *DB, baseLogger, verboseLogger logger.LogInterface, saveDiff bool) error {
+func CompareMySQL(a, b *DB, baseLogger *logger.Logger, saveDiff bool) error {
 	testConn, err := b.GetDB().Conn(context.TODO())
 	if err != nil {
 		return fmt.Errorf("could not grab connection from test db: %w", err)
 	}
 
 	tables := make([]string, 0)
-	rows, err := testConn.QueryContext(context.TODO(), "SHOW TABLES")
+	rows, err := b.queryContext(context.TODO(), testConn, "SHOW TABLES")
 	if err != nil {
 		return fmt.Errorf("could notget tables test db: %w", err)
 	}
@@ -98,17 +104,17 @@ func CompareMySQL(a, b *DB, baseLogger, verboseLogger logger.LogInterface, saveD
 		return fmt.Errorf("could not grab connection from actual db: %w", err)
 	}
 
-	baseLogger.Println("comparing tables...")
+	baseLogger.Info("comparing tables...")
 	var diffTables int
 	for _, table := range tables {
-		row := testConn.QueryRowContext(context.TODO(), fmt.Sprintf("SHOW CREATE TABLE %s", table))
+		row := b.queryRowContext(context.TODO(), testConn, fmt.Sprintf("SHOW CREATE TABLE %s", table))
 		var expected CreateTable
 		err = row.Scan(&expected.Table, &expected.CreateTable)
 		if err != nil {
 			return fmt.Errorf("could not get table definition from test db: %w", err)
 		}
 		var actual CreateTable
-		row = actualConn.QueryRowContext(context.TODO(), fmt.Sprintf("SHOW CREATE TABLE %s", table))
+		row = a.queryRowContext(context.TODO(), actualConn, fmt.Sprintf("SHOW CREATE TABLE %s", table))
 		err = row.Scan(&actual.Table, &actual.CreateTable)
 		if err != nil {
 			return fmt.Errorf("could not get table definition from actual db: %w", err)
@@ -119,10 +125,10 @@ func CompareMySQL(a, b *DB, baseLogger, verboseLogger logger.LogInterface, saveD
 			diffTables++
 
 			if !saveDiff {
-				baseLogger.Printf("%s table is not as expected. Diff:\n%s\n", table, diff)
+				baseLogger.Warn("table is not as expected", "table", table, "diff", diff)
 				continue
 			}
-			verboseLogger.Printf("%s table differs from what is expected.\n", table)
+			baseLogger.Warn("table differs from what is expected", "table", table)
 
 			_ = os.RemoveAll("diffs")
 			err = os.MkdirAll("diffs", 0750)
@@ -137,8 +143,110 @@ func CompareMySQL(a, b *DB, baseLogger, verboseLogger logger.LogInterface, saveD
 		}
 	}
 	if diffTables == 0 {
-		verboseLogger.Printf("MySQL tables are equal from what is expected.\n")
+		baseLogger.Debug("MySQL tables are equal to what is expected")
 	}
 
 	return nil
 }
+
+// TableStat holds the approximate size of a table as reported by the
+// information_schema.
+type TableStat struct {
+	Name        string
+	Rows        int64
+	DataLength  int64
+	IndexLength int64
+}
+
+// GetTableStats returns the estimated row count and the size of each table
+// in the MySQL database. The row counts are estimates for InnoDB tables.
+func (db *DB) GetTableStats(ctx context.Context) ([]TableStat, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, `SELECT TABLE_NAME, COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0)
+FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+ORDER BY TABLE_NAME`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query table stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []TableStat
+	for rows.Next() {
+		var s TableStat
+		if err := rows.Scan(&s.Name, &s.Rows, &s.DataLength, &s.IndexLength); err != nil {
+			return nil, fmt.Errorf("could not scan table stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Throughput is the amount of data that is read within a duration.
+type Throughput struct {
+	Rows     int64
+	Bytes    int64
+	Duration time.Duration
+}
+
+// RowsPerSecond returns the number of rows read in a second.
+func (t *Throughput) RowsPerSecond() float64 {
+	return float64(t.Rows) / t.Duration.Seconds()
+}
+
+// BytesPerSecond returns the number of bytes read in a second.
+func (t *Throughput) BytesPerSecond() float64 {
+	return float64(t.Bytes) / t.Duration.Seconds()
+}
+
+// BenchmarkRead measures the throughput of reading up to the given number of
+// rows of the table over a single connection.
+func (db *DB) BenchmarkRead(ctx context.Context, table string, limit int) (*Throughput, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	start := time.Now()
+	rows, err := db.queryContext(ctx, db.conn, fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteMySQLIdentifier(table), limit))
+	if err != nil {
+		return nil, fmt.Errorf("could not query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("could not get columns: %w", err)
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	t := &Throughput{}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		t.Rows++
+		for _, v := range values {
+			t.Bytes += int64(len(v))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+	t.Duration = time.Since(start)
+
+	return t, nil
+}
@@ -6,10 +6,20 @@ import (
 	"fmt"
 	"net/url"
 	"slices"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/isacikgoz/migration-assist/internal/logger"
 )
 
+// postgresColumnsQuery reports the enum type name in place of USER-DEFINED
+// so that callers can cast into the correct type.
+const postgresColumnsQuery = `SELECT table_name, column_name, data_type, udt_name
+FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema())
+ORDER BY table_name, ordinal_position`
+
 func openPostgres(dataSource string) (*DB, error) {
 	db, err := sql.Open("postgres", dataSource)
 	if err != nil {
@@ -43,8 +53,30 @@ func extractPostgresDatabaseNameFromURL(conn string) (string, error) {
 	return uri.Path[1:], nil
 }
 
-func (db *DB) CheckPostgresDefaultSchema(ctx context.Context, schema string, logger logger.LogInterface) error {
-	rows, err := db.db.QueryContext(ctx, "SHOW search_path")
+// SchemaExists reports whether the schema exists in the Postgres database.
+func (db *DB) SchemaExists(ctx context.Context, schema string) (bool, error) {
+	var count int
+	err := db.queryRowContext(ctx, db.conn, "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = $1", schema).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SearchPath returns the search_path of the session.
+func (db *DB) SearchPath(ctx context.Context) (string, error) {
+	var searchPath string
+	err := db.queryRowContext(ctx, db.conn, "SHOW SEARCH_PATH").Scan(&searchPath)
+	if err != nil {
+		return "", err
+	}
+
+	return searchPath, nil
+}
+
+func (db *DB) CheckPostgresDefaultSchema(ctx context.Context, schema string, logger *logger.Logger) error {
+	rows, err := db.queryContext(ctx, db.db, "SHOW search_path")
 	if err != nil {
 		return fmt.Errorf("could not determine the search_path: %w", err)
 	}
@@ -62,13 +94,163 @@ func (db *DB) CheckPostgresDefaultSchema(ctx context.Context, schema string, log
 	if len(schemas) == 0 {
 		return fmt.Errorf("no value available for search_path")
 	} else if _, ok := slices.BinarySearch(schemas, schema); !ok {
-		logger.Printf("could not find the default schema %q in search_path, consider setting it from the postgresql console\n", schema)
+		logger.Warn("could not find the default schema in search_path, consider setting it from the postgresql console", "schema", schema)
 		err := db.ExecQuery(ctx, fmt.Sprintf("SELECT pg_catalog.set_config('search_path', '\"$user\", %s', false)", schema))
 		if err != nil {
 			return fmt.Errorf("could not set search_path for the session: %w", err)
 		}
-		logger.Printf("search_path is set to %q for the currrent session\n", schema)
+		logger.Info("search_path is set for the current session", "schema", schema)
+	}
+
+	return nil
+}
+
+// AnalyzeTables collects the statistics of every table within the schema, the
+// tables are vacuumed as well if it's requested.
+func (db *DB) AnalyzeTables(ctx context.Context, schema string, vacuum bool, logger *logger.Logger) error {
+	tables, err := db.postgresTables(ctx, schema)
+	if err != nil {
+		return err
+	}
+
+	command := "ANALYZE"
+	if vacuum {
+		command = "VACUUM (ANALYZE)"
+	}
+
+	for i, table := range tables {
+		start := time.Now()
+		err = db.ExecQuery(ctx, fmt.Sprintf("%s %s.%s", command, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)))
+		if err != nil {
+			return fmt.Errorf("could not analyze %s: %w", table, err)
+		}
+		logger.Info(fmt.Sprintf("[%d/%d] table is analyzed", i+1, len(tables)), "table", table, "duration", time.Since(start).Round(time.Millisecond))
 	}
 
 	return nil
 }
+
+func (db *DB) postgresTables(ctx context.Context, schema string) ([]string, error) {
+	rows, err := db.queryContext(ctx, db.conn, "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = $1 ORDER BY tablename", schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not query tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("could not scan table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return tables, nil
+}
+
+// sequencesQuery lists the sequences that belong to serial or identity
+// columns along with their last values.
+const sequencesQuery = `SELECT s.relname, t.relname, a.attname, ps.last_value
+FROM pg_catalog.pg_class s
+JOIN pg_catalog.pg_namespace n ON n.oid = s.relnamespace
+JOIN pg_catalog.pg_depend d ON d.objid = s.oid AND d.classid = 'pg_catalog.pg_class'::regclass AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.deptype IN ('a', 'i')
+JOIN pg_catalog.pg_class t ON t.oid = d.refobjid
+JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = d.refobjsubid
+JOIN pg_catalog.pg_sequences ps ON ps.schemaname = n.nspname AND ps.sequencename = s.relname
+WHERE s.relkind = 'S' AND n.nspname = $1
+ORDER BY t.relname, a.attname`
+
+// ResetSequences advances the sequences of the serial and identity columns
+// that are behind the loaded data, as the data is loaded with explicit values.
+// It returns the number of sequences that are reset.
+func (db *DB) ResetSequences(ctx context.Context, schema string, logger *logger.Logger) (int, error) {
+	type sequence struct {
+		name, table, column string
+		lastValue           sql.NullInt64
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, sequencesQuery, schema)
+	if err != nil {
+		return 0, fmt.Errorf("could not query sequences: %w", err)
+	}
+
+	var sequences []sequence
+	for rows.Next() {
+		var s sequence
+		if err := rows.Scan(&s.name, &s.table, &s.column, &s.lastValue); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("could not scan sequence: %w", err)
+		}
+		sequences = append(sequences, s)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error during query: %w", err)
+	}
+
+	var reset int
+	for _, s := range sequences {
+		var maxValue sql.NullInt64
+		err := db.queryRowContext(ctx, db.conn, fmt.Sprintf("SELECT MAX(%s) FROM %s.%s", pq.QuoteIdentifier(s.column), pq.QuoteIdentifier(schema), pq.QuoteIdentifier(s.table))).Scan(&maxValue)
+		if err != nil {
+			return reset, fmt.Errorf("could not get the maximum value of %s.%s: %w", s.table, s.column, err)
+		}
+
+		if !maxValue.Valid || (s.lastValue.Valid && s.lastValue.Int64 >= maxValue.Int64) {
+			continue
+		}
+
+		err = db.ExecQuery(ctx, "SELECT pg_catalog.setval($1, $2, true)", pq.QuoteIdentifier(schema)+"."+pq.QuoteIdentifier(s.name), maxValue.Int64)
+		if err != nil {
+			return reset, fmt.Errorf("could not reset sequence %s: %w", s.name, err)
+		}
+
+		logger.Info("sequence is reset", "sequence", s.name, "table", s.table, "column", s.column, "value", maxValue.Int64)
+		reset++
+	}
+
+	return reset, nil
+}
+
+// Index is an index of a table.
+type Index struct {
+	Table string
+	Name  string
+}
+
+// InvalidIndexes returns the indexes within the schema that are left invalid,
+// e.g. by a failed concurrent index build.
+func (db *DB) InvalidIndexes(ctx context.Context, schema string) ([]Index, error) {
+	rows, err := db.queryContext(ctx, db.conn, `SELECT t.relname, c.relname
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+JOIN pg_catalog.pg_class t ON t.oid = i.indrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE NOT i.indisvalid AND n.nspname = $1
+ORDER BY t.relname, c.relname`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var idx Index
+		if err := rows.Scan(&idx.Table, &idx.Name); err != nil {
+			return nil, fmt.Errorf("could not scan index: %w", err)
+		}
+		indexes = append(indexes, idx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return indexes, nil
+}
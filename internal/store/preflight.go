@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ServerVersion returns the version string reported by the database server.
+func (db *DB) ServerVersion(ctx context.Context) (string, error) {
+	var query string
+	switch db.dbType {
+	case "mysql":
+		query = "SELECT VERSION()"
+	case "postgres":
+		query = "SHOW server_version"
+	default:
+		return "", fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	var version string
+	err := db.queryRowContext(ctx, db.conn, query).Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("could not read server version: %w", err)
+	}
+
+	return version, nil
+}
+
+// DatabaseName returns the name of the database that the store is connected to.
+func (db *DB) DatabaseName() string {
+	return db.databaseName
+}
+
+// MySQLGrants are the grants of the current user that apply to the database.
+type MySQLGrants struct {
+	Privileges map[string]bool
+	// Roles are the roles granted to the user, their privileges are not
+	// resolved.
+	Roles []string
+}
+
+// MySQLGrants returns the privileges of the current user that apply to the
+// database, as they are listed by SHOW GRANTS, along with the granted roles.
+func (db *DB) MySQLGrants(ctx context.Context) (*MySQLGrants, error) {
+	if db.dbType != "mysql" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, "SHOW GRANTS FOR CURRENT_USER()")
+	if err != nil {
+		return nil, fmt.Errorf("could not query grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := &MySQLGrants{Privileges: make(map[string]bool)}
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, fmt.Errorf("could not scan grant: %w", err)
+		}
+
+		for _, p := range parseMySQLGrant(grant, db.databaseName) {
+			grants.Privileges[p] = true
+		}
+		grants.Roles = append(grants.Roles, parseMySQLRoleGrant(grant)...)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return grants, nil
+}
+
+// parseMySQLGrant returns the privileges of a GRANT statement if it applies
+// to the database, e.g. GRANT SELECT, CREATE ROUTINE ON `mattermost`.* TO ...
+func parseMySQLGrant(grant, database string) []string {
+	rest, ok := strings.CutPrefix(grant, "GRANT ")
+	if !ok {
+		return nil
+	}
+
+	list, rest, ok := strings.Cut(rest, " ON ")
+	if !ok {
+		return nil
+	}
+
+	target, _, _ := strings.Cut(rest, " TO ")
+	target = strings.NewReplacer("`", "", `\`, "").Replace(target)
+	if target != "*.*" && target != database+".*" {
+		return nil
+	}
+
+	var privileges []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.ToUpper(strings.TrimSpace(p))
+		if p == "ALL" {
+			p = "ALL PRIVILEGES"
+		}
+		privileges = append(privileges, p)
+	}
+
+	return privileges
+}
+
+// parseMySQLRoleGrant returns the roles of a role grant, which has no ON
+// clause, e.g. GRANT `app_read`@`%`,`app_write`@`%` TO ...
+func parseMySQLRoleGrant(grant string) []string {
+	rest, ok := strings.CutPrefix(grant, "GRANT ")
+	if !ok || strings.Contains(rest, " ON ") {
+		return nil
+	}
+
+	list, _, ok := strings.Cut(rest, " TO ")
+	if !ok {
+		return nil
+	}
+
+	var roles []string
+	for _, r := range strings.Split(list, ",") {
+		roles = append(roles, strings.ReplaceAll(strings.TrimSpace(r), "`", ""))
+	}
+
+	return roles
+}
+
+// PostgresPrivileges are the privileges of the current user that the
+// pgloader load hooks depend on.
+type PostgresPrivileges struct {
+	User      string
+	Superuser bool
+	// SchemaOwner is required to rename the schema.
+	SchemaOwner bool
+	// CreateOnDatabase is required to rename the schema.
+	CreateOnDatabase bool
+	// CreateRole is required to alter the session defaults of other roles.
+	CreateRole bool
+	// TablesWithoutWrite are the tables that the user can't insert into or
+	// truncate.
+	TablesWithoutWrite []string
+}
+
+// GetPostgresPrivileges returns the privileges of the current user on the
+// schema and its tables.
+func (db *DB) GetPostgresPrivileges(ctx context.Context, schema string) (*PostgresPrivileges, error) {
+	if db.dbType != "postgres" {
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	p := &PostgresPrivileges{}
+	err := db.queryRowContext(ctx, db.conn, `SELECT r.rolname, r.rolsuper, r.rolcreaterole,
+	has_database_privilege(current_database(), 'CREATE'),
+	COALESCE((SELECT pg_has_role(current_user, n.nspowner, 'USAGE') FROM pg_catalog.pg_namespace n WHERE n.nspname = $1), false)
+FROM pg_catalog.pg_roles r
+WHERE r.rolname = current_user`, schema).Scan(&p.User, &p.Superuser, &p.CreateRole, &p.CreateOnDatabase, &p.SchemaOwner)
+	if err != nil {
+		return nil, fmt.Errorf("could not query privileges: %w", err)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, `SELECT c.relname
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relkind IN ('r', 'p')
+AND NOT (has_table_privilege(c.oid, 'INSERT') AND has_table_privilege(c.oid, 'TRUNCATE'))
+ORDER BY c.relname`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not query table privileges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("could not scan table: %w", err)
+		}
+		p.TablesWithoutWrite = append(p.TablesWithoutWrite, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return p, nil
+}
+
+// ConnectionLimits is the connection capacity of a database server.
+type ConnectionLimits struct {
+	Max int
+	// Reserved are the connections reserved for the superusers.
+	Reserved int
+	InUse    int
+}
+
+// Available returns the number of connections that can still be opened by
+// an ordinary user.
+func (l *ConnectionLimits) Available() int {
+	return l.Max - l.Reserved - l.InUse
+}
+
+// GetConnectionLimits returns the maximum number of connections of the server
+// and the number of connections that are in use.
+func (db *DB) GetConnectionLimits(ctx context.Context) (*ConnectionLimits, error) {
+	l := &ConnectionLimits{}
+
+	switch db.dbType {
+	case "mysql":
+		err := db.queryRowContext(ctx, db.conn, "SELECT @@max_connections").Scan(&l.Max)
+		if err != nil {
+			return nil, fmt.Errorf("could not read max_connections: %w", err)
+		}
+
+		var name string
+		err = db.queryRowContext(ctx, db.conn, "SHOW GLOBAL STATUS LIKE 'Threads_connected'").Scan(&name, &l.InUse)
+		if err != nil {
+			return nil, fmt.Errorf("could not read connected threads: %w", err)
+		}
+	case "postgres":
+		err := db.queryRowContext(ctx, db.conn, `SELECT current_setting('max_connections')::int,
+	current_setting('superuser_reserved_connections')::int,
+	(SELECT COUNT(*) FROM pg_catalog.pg_stat_activity WHERE backend_type = 'client backend')`).Scan(&l.Max, &l.Reserved, &l.InUse)
+		if err != nil {
+			return nil, fmt.Errorf("could not read connection limits: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	return l, nil
+}
+
+// DataDirectory returns the data directory of the Postgres server, reading
+// it requires the superuser or the pg_read_all_settings role.
+func (db *DB) DataDirectory(ctx context.Context) (string, error) {
+	if db.dbType != "postgres" {
+		return "", fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	var dir string
+	err := db.queryRowContext(ctx, db.conn, "SHOW data_directory").Scan(&dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read data directory: %w", err)
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseMySQLGrant(t *testing.T) {
+	tests := []struct {
+		name       string
+		grant      string
+		database   string
+		privileges []string
+	}{
+		{
+			name:       "database privileges",
+			grant:      "GRANT SELECT, CREATE ROUTINE, alter routine ON `mattermost`.* TO `mmuser`@`%`",
+			privileges: []string{"SELECT", "CREATE ROUTINE", "ALTER ROUTINE"},
+		},
+		{
+			name:       "global privileges",
+			grant:      "GRANT ALL ON *.* TO `root`@`localhost` WITH GRANT OPTION",
+			privileges: []string{"ALL PRIVILEGES"},
+		},
+		{
+			name:       "escaped database name",
+			grant:      "GRANT SELECT ON `mattermost\\_test`.* TO `mmuser`@`%`",
+			database:   "mattermost_test",
+			privileges: []string{"SELECT"},
+		},
+		{
+			name:  "other database",
+			grant: "GRANT SELECT ON `other`.* TO `mmuser`@`%`",
+		},
+		{
+			name:  "table privileges",
+			grant: "GRANT SELECT ON `mattermost`.`Posts` TO `mmuser`@`%`",
+		},
+		{
+			name:  "role grant",
+			grant: "GRANT `app_read`@`%` TO `mmuser`@`%`",
+		},
+		{
+			name:  "proxy grant",
+			grant: "GRANT PROXY ON ``@`` TO `root`@`localhost` WITH GRANT OPTION",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			database := tc.database
+			if database == "" {
+				database = "mattermost"
+			}
+
+			if got := parseMySQLGrant(tc.grant, database); !slices.Equal(got, tc.privileges) {
+				t.Errorf("expected %v, got %v", tc.privileges, got)
+			}
+		})
+	}
+}
+
+func TestParseMySQLRoleGrant(t *testing.T) {
+	tests := []struct {
+		name  string
+		grant string
+		roles []string
+	}{
+		{
+			name:  "single role",
+			grant: "GRANT `app_read`@`%` TO `mmuser`@`%`",
+			roles: []string{"app_read@%"},
+		},
+		{
+			name:  "multiple roles",
+			grant: "GRANT `app_read`@`%`,`app_write`@`localhost` TO `mmuser`@`%` WITH ADMIN OPTION",
+			roles: []string{"app_read@%", "app_write@localhost"},
+		},
+		{
+			name:  "privilege grant",
+			grant: "GRANT SELECT ON `mattermost`.* TO `mmuser`@`%`",
+		},
+		{
+			name:  "not a grant",
+			grant: "REVOKE `app_read`@`%` FROM `mmuser`@`%`",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseMySQLRoleGrant(tc.grant); !slices.Equal(got, tc.roles) {
+				t.Errorf("expected %v, got %v", tc.roles, got)
+			}
+		})
+	}
+}
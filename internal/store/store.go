@@ -1,19 +1,31 @@
 package store
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mattermost/morph"
 	"github.com/mattermost/morph/drivers"
 	"github.com/mattermost/morph/drivers/mysql"
 	"github.com/mattermost/morph/drivers/postgres"
-	"github.com/mattermost/morph/sources/file"
+	"github.com/mattermost/morph/models"
+	"github.com/mattermost/morph/sources/embedded"
 
+	"github.com/isacikgoz/migration-assist/internal/audit"
 	"github.com/isacikgoz/migration-assist/internal/logger"
 )
 
@@ -67,74 +79,546 @@ func (db *DB) Close() error {
 }
 
 func (db *DB) RunSelectCountQuery(ctx context.Context, query string) (int, error) {
+	start := time.Now()
+
 	var count int
 	err := db.conn.QueryRowContext(ctx, query).Scan(&count)
 
+	rows := int64(count)
+	if err != nil {
+		rows = -1
+	}
+
+	db.audit(audit.KindSelect, query, nil, rows, start, err)
+
 	return count, err
 }
 
-func (db *DB) ExecQuery(ctx context.Context, query string) error {
-	_, err := db.conn.ExecContext(ctx, query)
+func (db *DB) ExecQuery(ctx context.Context, query string, args ...any) error {
+	_, err := db.execContext(ctx, db.conn, query, args...)
 
 	return err
 }
 
-// RunMigrations will run all of the migrations within a directory,
-func (db *DB) RunEmbeddedMigrations(assets embed.FS, dir string, logger logger.LogInterface) error {
+// createIndexRegex matches the names of the indexes created by a query.
+var createIndexRegex = regexp.MustCompile(`(?i)CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+
+// stepsTable records the embedded migrations that are applied, so that they
+// are not applied again if the run is resumed after a failure. It's created
+// within the Mattermost schema and should be dropped once the migration is
+// completed.
+const stepsTable = "migration_assist_steps"
+
+// EmbeddedMigrationOptions configures how the embedded migrations are run.
+type EmbeddedMigrationOptions struct {
+	// Schema is passed to the query templates as a quoted identifier.
+	Schema string
+	// Concurrently is passed to the query templates so that the indexes are
+	// built without blocking the writes.
+	Concurrently bool
+	// TextSearchConfig is passed to the query templates to build the full-text
+	// search indexes, english is used if it's empty.
+	TextSearchConfig string
+	// MaintenanceWorkMem sets maintenance_work_mem for the sessions if it's set.
+	MaintenanceWorkMem string
+	// MaxParallelMaintenanceWorkers sets max_parallel_maintenance_workers for
+	// the sessions if it's not negative.
+	MaxParallelMaintenanceWorkers int
+	// Parallel is the number of migrations to be run at the same time, each of
+	// them runs on a separate connection.
+	Parallel int
+	// ProgressInterval is the interval to report the progress of the index
+	// builds, the progress is not reported if it's zero.
+	ProgressInterval time.Duration
+}
+
+// RunEmbeddedMigrations will run all of the migrations within a directory,
+// the queries are rendered as templates with the given options. The applied
+// migrations are recorded, hence they are skipped on the consecutive runs
+// unless they build full-text search indexes with another text search
+// configuration than the existing ones.
+func (db *DB) RunEmbeddedMigrations(assets embed.FS, dir string, opts EmbeddedMigrationOptions, logger *logger.Logger) error {
+	if db.dbType != "postgres" {
+		return fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
 	queries, err := assets.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", dir, err)
+	}
+
+	applied, err := db.appliedSteps(context.TODO(), opts.Schema)
 	if err != nil {
 		return err
 	}
 
+	params := struct {
+		Schema           string
+		Concurrently     bool
+		TextSearchConfig string
+	}{
+		Schema:           pq.QuoteIdentifier(opts.Schema),
+		Concurrently:     opts.Concurrently,
+		TextSearchConfig: opts.TextSearchConfig,
+	}
+	if params.TextSearchConfig == "" {
+		params.TextSearchConfig = defaultTextSearchConfig
+	}
+
+	// the steps are prepared before any of them runs, so that an error
+	// doesn't leave the builds of the earlier steps running in the background
+	type pendingStep struct {
+		name  string
+		step  string
+		query string
+	}
+
+	var pending []pendingStep
 	for _, query := range queries {
-		b, err := assets.ReadFile(filepath.Join("post-migrate", query.Name()))
+		if query.IsDir() {
+			continue
+		}
+
+		step := path.Join(dir, query.Name())
+		b, err := assets.ReadFile(step)
 		if err != nil {
 			return fmt.Errorf("could not read embedded sql file: %w", err)
 		}
 
-		logger.Printf("applying %s\n", query.Name())
-		err = db.ExecQuery(context.TODO(), string(b))
+		templ, err := template.New(query.Name()).Parse(string(b))
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %w", query.Name(), err)
+		}
+
+		var buf bytes.Buffer
+		err = templ.Execute(&buf, params)
 		if err != nil {
-			return fmt.Errorf("error during running post-migrate queries: %w", err)
+			return fmt.Errorf("could not render %s: %w", query.Name(), err)
 		}
+
+		if applied[step] {
+			// the indexes are built again if the text search configuration
+			// is changed since they were applied
+			stale, err := db.staleTextSearchIndexes(context.TODO(), opts.Schema, buf.String())
+			if err != nil {
+				return err
+			}
+
+			if len(stale) == 0 {
+				logger.Info("already applied, skipping", "step", query.Name())
+				continue
+			}
+
+			for _, index := range stale {
+				logger.Warn("dropping index to build it with the new text search configuration", "step", query.Name(), "index", index, "text_search_config", params.TextSearchConfig)
+				err = db.ExecQuery(context.TODO(), fmt.Sprintf("DROP INDEX %sIF EXISTS %s.%s", concurrentlyClause(opts.Concurrently), pq.QuoteIdentifier(opts.Schema), pq.QuoteIdentifier(index)))
+				if err != nil {
+					return fmt.Errorf("could not drop index %s: %w", index, err)
+				}
+			}
+		}
+
+		pending = append(pending, pendingStep{name: query.Name(), step: step, query: buf.String()})
+	}
+
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	done := make(chan struct{})
+	if opts.ProgressInterval > 0 {
+		go db.reportIndexProgress(opts.ProgressInterval, done, logger)
+	}
+	defer close(done)
+
+	var wg sync.WaitGroup
+	var failedMut sync.Mutex
+	var failed []string
+	sem := make(chan struct{}, parallel)
+
+	for _, p := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p pendingStep) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			logger.Info("applying", "step", p.name)
+			start := time.Now()
+
+			err := db.runEmbeddedMigration(context.TODO(), p.step, p.query, opts, logger)
+			if err != nil {
+				logger.Error("could not apply", "step", p.name, "err", err)
+				failedMut.Lock()
+				failed = append(failed, p.name)
+				failedMut.Unlock()
+				return
+			}
+
+			logger.Info("applied", "step", p.name, "duration", time.Since(start).Round(time.Second))
+		}(p)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("error during running %s queries, %d failed: %s", dir, len(failed), strings.Join(failed, ", "))
 	}
 
 	return nil
 }
 
-// RunMigrations will run the migrations form a given directory with morph
-func (db *DB) RunMigrations(dir string) error {
+// runEmbeddedMigration runs the query on a dedicated connection with the
+// session settings and records it as applied. The invalid indexes left by an
+// interrupted concurrent build are dropped before the query is retried, since
+// IF NOT EXISTS would keep them as they are, and the step is not recorded
+// unless the indexes it creates are valid.
+func (db *DB) runEmbeddedMigration(ctx context.Context, step, query string, opts EmbeddedMigrationOptions, logger *logger.Logger) error {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("could not grab connection: %w", err)
+	}
+	defer conn.Close()
+
+	if opts.MaintenanceWorkMem != "" {
+		_, err = db.execContext(ctx, conn, "SELECT pg_catalog.set_config('maintenance_work_mem', $1, false)", opts.MaintenanceWorkMem)
+		if err != nil {
+			return fmt.Errorf("could not set maintenance_work_mem: %w", err)
+		}
+	}
+
+	if opts.MaxParallelMaintenanceWorkers >= 0 {
+		_, err = db.execContext(ctx, conn, "SELECT pg_catalog.set_config('max_parallel_maintenance_workers', $1, false)", strconv.Itoa(opts.MaxParallelMaintenanceWorkers))
+		if err != nil {
+			return fmt.Errorf("could not set max_parallel_maintenance_workers: %w", err)
+		}
+	}
+
+	indexes := createIndexRegex.FindAllStringSubmatch(query, -1)
+	for _, m := range indexes {
+		valid, exists, err2 := db.indexValidity(ctx, conn, opts.Schema, m[1])
+		if err2 != nil {
+			return err2
+		}
+		if !exists || valid {
+			continue
+		}
+
+		logger.Warn("dropping invalid index before building it again", "index", m[1])
+		_, err = db.execContext(ctx, conn, fmt.Sprintf("DROP INDEX %sIF EXISTS %s.%s", concurrentlyClause(opts.Concurrently), pq.QuoteIdentifier(opts.Schema), pq.QuoteIdentifier(m[1])))
+		if err != nil {
+			return fmt.Errorf("could not drop invalid index %s: %w", m[1], err)
+		}
+	}
+
+	_, err = db.execContext(ctx, conn, query)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range indexes {
+		valid, exists, err2 := db.indexValidity(ctx, conn, opts.Schema, m[1])
+		if err2 != nil {
+			return err2
+		}
+		if exists && !valid {
+			return fmt.Errorf("index %s is invalid after the build", m[1])
+		}
+	}
+
+	_, err = db.execContext(ctx, conn, fmt.Sprintf("INSERT INTO %s.%s (name, applied_at) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET applied_at = EXCLUDED.applied_at", pq.QuoteIdentifier(opts.Schema), stepsTable), step, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("could not record as applied: %w", err)
+	}
+
+	return nil
+}
+
+func concurrentlyClause(concurrently bool) string {
+	if concurrently {
+		return "CONCURRENTLY "
+	}
+
+	return ""
+}
+
+// indexValidity returns whether the index exists within the schema and whether
+// it's valid.
+func (db *DB) indexValidity(ctx context.Context, conn *sql.Conn, schema, name string) (bool, bool, error) {
+	var valid bool
+	err := db.queryRowContext(ctx, conn, `SELECT i.indisvalid
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relname = $2`, schema, strings.ToLower(name)).Scan(&valid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, fmt.Errorf("could not check index %s: %w", name, err)
+	}
+
+	return valid, true, nil
+}
+
+// reportIndexProgress logs the progress of the index builds periodically
+// until the done channel is closed. It queries through the pool, since the
+// connection of the store may be in use at the same time, and a failed poll
+// is retried on the next tick.
+func (db *DB) reportIndexProgress(interval time.Duration, done <-chan struct{}, logger *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		rows, err := db.queryContext(context.TODO(), db.db, `SELECT relid::regclass::text, phase, blocks_done, blocks_total, tuples_done, tuples_total
+FROM pg_stat_progress_create_index`)
+		if err != nil {
+			logger.Warn("could not query index build progress", "err", err)
+			continue
+		}
+
+		for rows.Next() {
+			var table, phase string
+			var blocksDone, blocksTotal, tuplesDone, tuplesTotal int64
+			if err := rows.Scan(&table, &phase, &blocksDone, &blocksTotal, &tuplesDone, &tuplesTotal); err != nil {
+				logger.Warn("could not scan index build progress", "err", err)
+				break
+			}
+
+			switch {
+			case blocksTotal > 0:
+				logger.Info("building index", "table", table, "phase", phase, "blocks_done", blocksDone, "blocks_total", blocksTotal, "percent", fmt.Sprintf("%.1f", float64(blocksDone)*100/float64(blocksTotal)))
+			case tuplesTotal > 0:
+				logger.Info("building index", "table", table, "phase", phase, "tuples_done", tuplesDone, "tuples_total", tuplesTotal, "percent", fmt.Sprintf("%.1f", float64(tuplesDone)*100/float64(tuplesTotal)))
+			default:
+				logger.Info("building index", "table", table, "phase", phase)
+			}
+		}
+		rows.Close()
+	}
+}
+
+// appliedSteps creates the bookkeeping table if it doesn't exist and returns
+// the steps that are already applied.
+func (db *DB) appliedSteps(ctx context.Context, schema string) (map[string]bool, error) {
+	err := db.ExecQuery(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (name VARCHAR(255) PRIMARY KEY, applied_at BIGINT NOT NULL)", pq.QuoteIdentifier(schema), stepsTable))
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s table: %w", stepsTable, err)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, fmt.Sprintf("SELECT name FROM %s.%s", pq.QuoteIdentifier(schema), stepsTable))
+	if err != nil {
+		return nil, fmt.Errorf("could not query applied steps: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("could not scan applied step: %w", err)
+		}
+		applied[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return applied, nil
+}
+
+// MigrationOptions limits the migrations to be applied or rolled back. The
+// zero value applies all of the pending migrations.
+type MigrationOptions struct {
+	// ToVersion is the version that the schema should be at. Migrations up to
+	// and including it are applied, or the ones after it are rolled back.
+	ToVersion int
+	// Steps is the maximum number of migrations to be applied or rolled back.
+	Steps int
+	// Down rolls back the applied migrations.
+	Down bool
+}
+
+// RunMigrations will run the migrations form a given source with morph. The
+// plan is logged before the migrations are executed, and the migrations are
+// executed one by one to report the time each of them took. The output of
+// morph is written to the verbose logger.
+func (db *DB) RunMigrations(src fs.FS, opts MigrationOptions, baseLogger *logger.Logger) error {
+	engine, err := db.newMorph(src, baseLogger.Printer(slog.LevelDebug))
+	if err != nil {
+		return err
+	}
+
+	direction := models.Up
+	if opts.Down {
+		direction = models.Down
+	}
+
+	diff, err := engine.Diff(direction)
+	if err != nil {
+		return fmt.Errorf("could not compute migrations: %w", err)
+	}
+
+	plan := planMigrations(diff, opts)
+
+	if len(plan) == 0 {
+		baseLogger.Info("no migrations to run")
+		return nil
+	}
+
+	baseLogger.Info(fmt.Sprintf("the following %d migration(s) will run", len(plan)))
+	for _, m := range plan {
+		baseLogger.Info("planned", "migration", m.RawName)
+	}
+
+	start := time.Now()
+	elapsed := make([]time.Duration, 0, len(plan))
+	for i, m := range plan {
+		baseLogger.Info(fmt.Sprintf("[%d/%d] running", i+1, len(plan)), "migration", m.RawName)
+		migrationStart := time.Now()
+
+		if opts.Down {
+			_, err = engine.ApplyDown(1)
+		} else {
+			_, err = engine.Apply(1)
+		}
+		db.audit(audit.KindMigration, fmt.Sprintf("-- %s\n%s", m.RawName, m.Query()), nil, -1, migrationStart, err)
+		if err != nil {
+			return fmt.Errorf("could not apply migration %s after %s: %w", m.RawName, time.Since(migrationStart).Round(time.Millisecond), err)
+		}
+
+		elapsed = append(elapsed, time.Since(migrationStart))
+		baseLogger.Info(fmt.Sprintf("[%d/%d] completed", i+1, len(plan)), "migration", m.RawName, "duration", elapsed[i].Round(time.Millisecond))
+	}
+
+	baseLogger.Info(fmt.Sprintf("%d migration(s) completed", len(plan)), "duration", time.Since(start).Round(time.Millisecond))
+	for i, m := range plan {
+		baseLogger.Info("summary", "migration", m.RawName, "duration", elapsed[i].Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+func (db *DB) newMorph(src fs.FS, morphLogger logger.LogInterface) (*morph.Morph, error) {
 	var driver drivers.Driver
 	var err error
 	switch db.dbType {
 	case "mysql":
 		driver, err = mysql.WithInstance(db.db)
 		if err != nil {
-			return fmt.Errorf("could not initialize driver: %w", err)
+			return nil, fmt.Errorf("could not initialize driver: %w", err)
 		}
 	case "postgres":
 		driver, err = postgres.WithInstance(db.db)
 		if err != nil {
-			return fmt.Errorf("could not initialize driver: %w", err)
+			return nil, fmt.Errorf("could not initialize driver: %w", err)
 		}
 	default:
-		return fmt.Errorf("unsupported db type: %s", db.dbType)
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
 	}
 
-	src, err := file.Open(dir)
+	files, err := fs.ReadDir(src, ".")
 	if err != nil {
-		return fmt.Errorf("could not read migrations: %w", err)
+		return nil, fmt.Errorf("could not read migrations: %w", err)
 	}
 
-	engine, err := morph.New(context.TODO(), driver, src, morph.WithLogger(logger.NewNopLogger()))
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+
+	migrations, err := embedded.WithInstance(embedded.Resource(names, func(name string) ([]byte, error) {
+		return fs.ReadFile(src, name)
+	}))
 	if err != nil {
-		return fmt.Errorf("could not initialize morph: %w", err)
+		return nil, fmt.Errorf("could not read migrations: %w", err)
 	}
 
-	err = engine.ApplyAll()
+	engine, err := morph.New(context.TODO(), driver, migrations, morph.WithLogger(morphLogger))
 	if err != nil {
-		return fmt.Errorf("could not apply migrations: %w", err)
+		return nil, fmt.Errorf("could not initialize morph: %w", err)
 	}
 
-	return nil
+	return engine, nil
+}
+
+// planMigrations returns the migrations of the diff to be executed in order.
+// The diff is in the same order as morph applies them, hence the first n
+// migrations of the plan are executed by applying n migrations.
+func planMigrations(diff []*models.Migration, opts MigrationOptions) []*models.Migration {
+	var plan []*models.Migration
+	for _, m := range diff {
+		if opts.ToVersion > 0 {
+			if !opts.Down && int(m.Version) > opts.ToVersion {
+				break
+			}
+			if opts.Down && int(m.Version) <= opts.ToVersion {
+				break
+			}
+		}
+
+		if opts.Steps > 0 && len(plan) == opts.Steps {
+			break
+		}
+
+		plan = append(plan, m)
+	}
+
+	return plan
+}
+
+// Column represents a single column of a table as reported by the
+// information_schema of the database. ColumnType holds the full column
+// definition for MySQL (e.g. tinyint(1)) and the udt name for Postgres.
+type Column struct {
+	Table      string
+	Name       string
+	DataType   string
+	ColumnType string
+}
+
+// GetColumns returns the columns of every table within the given schema. If
+// the schema is empty, the current database (or schema for Postgres) is used.
+func (db *DB) GetColumns(ctx context.Context, schema string) ([]Column, error) {
+	var query string
+	switch db.dbType {
+	case "mysql":
+		query = mysqlColumnsQuery
+	case "postgres":
+		query = postgresColumnsQuery
+	default:
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("could not query columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Table, &c.Name, &c.DataType, &c.ColumnType); err != nil {
+			return nil, fmt.Errorf("could not scan column: %w", err)
+		}
+		columns = append(columns, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return columns, nil
 }
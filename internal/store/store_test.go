@@ -0,0 +1,91 @@
+package store
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mattermost/morph/models"
+)
+
+func TestPlanMigrations(t *testing.T) {
+	migrations := func(versions ...uint32) []*models.Migration {
+		var diff []*models.Migration
+		for _, v := range versions {
+			diff = append(diff, &models.Migration{Version: v})
+		}
+		return diff
+	}
+
+	up := migrations(101, 102, 103, 104)
+	down := migrations(104, 103, 102, 101)
+
+	tests := []struct {
+		name     string
+		diff     []*models.Migration
+		opts     MigrationOptions
+		expected []uint32
+	}{
+		{
+			name:     "all pending migrations",
+			diff:     up,
+			expected: []uint32{101, 102, 103, 104},
+		},
+		{
+			name:     "up to a version",
+			diff:     up,
+			opts:     MigrationOptions{ToVersion: 102},
+			expected: []uint32{101, 102},
+		},
+		{
+			name:     "up by steps",
+			diff:     up,
+			opts:     MigrationOptions{Steps: 3},
+			expected: []uint32{101, 102, 103},
+		},
+		{
+			name:     "steps beyond the version",
+			diff:     up,
+			opts:     MigrationOptions{ToVersion: 101, Steps: 3},
+			expected: []uint32{101},
+		},
+		{
+			name:     "version beyond the steps",
+			diff:     up,
+			opts:     MigrationOptions{ToVersion: 104, Steps: 2},
+			expected: []uint32{101, 102},
+		},
+		{
+			name: "up to an applied version",
+			diff: up,
+			opts: MigrationOptions{ToVersion: 100},
+		},
+		{
+			name:     "down to a version",
+			diff:     down,
+			opts:     MigrationOptions{Down: true, ToVersion: 102},
+			expected: []uint32{104, 103},
+		},
+		{
+			name:     "down by steps",
+			diff:     down,
+			opts:     MigrationOptions{Down: true, Steps: 1},
+			expected: []uint32{104},
+		},
+		{
+			name: "nothing to run",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var versions []uint32
+			for _, m := range planMigrations(tc.diff, tc.opts) {
+				versions = append(versions, m.Version)
+			}
+
+			if !slices.Equal(versions, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, versions)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const defaultTextSearchConfig = "english"
+
+// textSearchConfigRegex matches the text search configuration of a query or
+// an index definition, e.g. to_tsvector('german'::regconfig, message).
+var textSearchConfigRegex = regexp.MustCompile(`to_tsvector\('([^']+)'`)
+
+// localeTextSearchConfigs maps the Mattermost locales to the text search
+// configurations that are shipped with Postgres. The locales without a
+// stemmer, e.g. Japanese or Chinese, use the simple configuration.
+var localeTextSearchConfigs = map[string]string{
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// TextSearchConfigForLocale returns the text search configuration for a
+// Mattermost locale such as de or pt-BR.
+func TextSearchConfigForLocale(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	if config, ok := localeTextSearchConfigs[lang]; ok {
+		return config
+	}
+
+	return "simple"
+}
+
+// GetDefaultLocale reads the default locale of the users from the active
+// configuration stored in the database. The locale can't be detected if
+// the configuration is stored in a file.
+func (db *DB) GetDefaultLocale(ctx context.Context) (string, error) {
+	var query string
+	switch db.dbType {
+	case "mysql":
+		query = "SELECT Value FROM Configurations WHERE Active = 1"
+	case "postgres":
+		query = "SELECT value FROM configurations WHERE active = true"
+	default:
+		return "", fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	var value string
+	err := db.queryRowContext(ctx, db.conn, query).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New("there is no active configuration in the database")
+	} else if err != nil {
+		return "", fmt.Errorf("could not read the configuration: %w", err)
+	}
+
+	var config struct {
+		LocalizationSettings struct {
+			DefaultServerLocale string
+			DefaultClientLocale string
+		}
+	}
+	err = json.Unmarshal([]byte(value), &config)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the configuration: %w", err)
+	}
+
+	if locale := config.LocalizationSettings.DefaultClientLocale; locale != "" {
+		return locale, nil
+	} else if locale := config.LocalizationSettings.DefaultServerLocale; locale != "" {
+		return locale, nil
+	}
+
+	return "", errors.New("the default locale is not set in the configuration")
+}
+
+// TextSearchConfigExists reports whether the text search configuration exists
+// in the Postgres database.
+func (db *DB) TextSearchConfigExists(ctx context.Context, config string) (bool, error) {
+	var count int
+	err := db.queryRowContext(ctx, db.conn, "SELECT COUNT(*) FROM pg_catalog.pg_ts_config WHERE cfgname = $1", config).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// DefaultTextSearchConfig returns the default_text_search_config of the
+// session, which is what the search queries of Mattermost use.
+func (db *DB) DefaultTextSearchConfig(ctx context.Context) (string, error) {
+	var config string
+	err := db.queryRowContext(ctx, db.conn, "SHOW default_text_search_config").Scan(&config)
+	if err != nil {
+		return "", fmt.Errorf("could not read default_text_search_config: %w", err)
+	}
+
+	return config, nil
+}
+
+// SetDefaultTextSearchConfig sets the default_text_search_config of the
+// database, it applies to the sessions that are opened afterwards.
+func (db *DB) SetDefaultTextSearchConfig(ctx context.Context, config string) error {
+	return db.ExecQuery(ctx, fmt.Sprintf("ALTER DATABASE %s SET default_text_search_config = %s", pq.QuoteIdentifier(db.databaseName), pq.QuoteLiteral(qualifyTextSearchConfig(config))))
+}
+
+// SameTextSearchConfig reports whether the configurations are the same, the
+// configurations that are not schema qualified are in pg_catalog.
+func SameTextSearchConfig(a, b string) bool {
+	return qualifyTextSearchConfig(a) == qualifyTextSearchConfig(b)
+}
+
+func qualifyTextSearchConfig(config string) string {
+	if strings.Contains(config, ".") {
+		return config
+	}
+
+	return "pg_catalog." + config
+}
+
+// staleTextSearchIndexes returns the indexes that are created by the query
+// but exist with a different text search configuration than the query uses.
+func (db *DB) staleTextSearchIndexes(ctx context.Context, schema, query string) ([]string, error) {
+	m := textSearchConfigRegex.FindStringSubmatch(query)
+	if m == nil {
+		return nil, nil
+	}
+
+	var stale []string
+	for _, idx := range createIndexRegex.FindAllStringSubmatch(query, -1) {
+		var def string
+		err := db.queryRowContext(ctx, db.conn, `SELECT pg_catalog.pg_get_indexdef(c.oid)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'i' AND n.nspname = $1 AND c.relname = $2`, schema, strings.ToLower(idx[1])).Scan(&def)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read the definition of %s: %w", idx[1], err)
+		}
+
+		if existing := textSearchConfigRegex.FindStringSubmatch(def); existing != nil && !SameTextSearchConfig(existing[1], m[1]) {
+			stale = append(stale, idx[1])
+		}
+	}
+
+	return stale, nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestTextSearchConfigForLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		config string
+	}{
+		{locale: "de", config: "german"},
+		{locale: "EN", config: "english"},
+		{locale: "pt-BR", config: "portuguese"},
+		{locale: "zh-CN", config: "simple"},
+		{locale: "ja", config: "simple"},
+		{locale: "", config: "simple"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.locale, func(t *testing.T) {
+			if got := TextSearchConfigForLocale(tc.locale); got != tc.config {
+				t.Errorf("expected %s, got %s", tc.config, got)
+			}
+		})
+	}
+}
+
+func TestSameTextSearchConfig(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{a: "english", b: "english", same: true},
+		{a: "english", b: "pg_catalog.english", same: true},
+		{a: "pg_catalog.german", b: "german", same: true},
+		{a: "english", b: "german", same: false},
+		{a: "public.english", b: "english", same: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			if got := SameTextSearchConfig(tc.a, tc.b); got != tc.same {
+				t.Errorf("expected %t, got %t", tc.same, got)
+			}
+		})
+	}
+}
+
+func TestTextSearchConfigRegex(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		config string
+	}{
+		{
+			name:   "index definition",
+			query:  "CREATE INDEX idx_posts_message_txt ON public.posts USING gin (to_tsvector('german'::regconfig, (message)::text))",
+			config: "german",
+		},
+		{
+			name:   "template",
+			query:  "CREATE INDEX IF NOT EXISTS idx_posts_message_txt ON posts USING gin(to_tsvector('english', message));",
+			config: "english",
+		},
+		{
+			name:  "without text search",
+			query: "CREATE INDEX idx_posts_create_at ON posts (createat)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if m := textSearchConfigRegex.FindStringSubmatch(tc.query); m != nil {
+				got = m[1]
+			}
+			if got != tc.config {
+				t.Errorf("expected %q, got %q", tc.config, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+)
+
+// Migration is a morph migration recorded in the db_migrations table.
+type Migration struct {
+	Version int
+	Name    string
+}
+
+// InstalledVersion is the Mattermost version that the database schema
+// belongs to along with the last migration that is applied.
+type InstalledVersion struct {
+	Version       semver.Version
+	LastMigration *Migration
+}
+
+// DetectMattermostVersion reads the schema version from the Systems table
+// and the last applied migration from the db_migrations table.
+func (db *DB) DetectMattermostVersion(ctx context.Context) (*InstalledVersion, error) {
+	var versionQuery, migrationQuery string
+	switch db.dbType {
+	case "mysql":
+		versionQuery = "SELECT Value FROM Systems WHERE Name = 'Version'"
+		migrationQuery = "SELECT Version, Name FROM db_migrations ORDER BY Version DESC LIMIT 1"
+	case "postgres":
+		versionQuery = "SELECT value FROM systems WHERE name = 'Version'"
+		migrationQuery = "SELECT version, name FROM db_migrations ORDER BY version DESC LIMIT 1"
+	default:
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	var value string
+	err := db.queryRowContext(ctx, db.conn, versionQuery).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("the version is not recorded in the Systems table")
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read the version from the Systems table: %w", err)
+	}
+
+	v, err := semver.ParseTolerant(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse version %q: %w", value, err)
+	}
+
+	installed := &InstalledVersion{Version: v}
+
+	var m Migration
+	err = db.queryRowContext(ctx, db.conn, migrationQuery).Scan(&m.Version, &m.Name)
+	if err == nil {
+		installed.LastMigration = &m
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not read the last migration: %w", err)
+	}
+
+	return installed, nil
+}
+
+// migrationRenames are the migrations that are recorded with different names
+// by the drivers. The MySQL name is the first one, and the pgloader
+// configuration renames the Postgres record to it after loading the data.
+var migrationRenames = map[int][2]string{
+	92: {"add_createat_to_teamembers", "add_createat_to_teammembers"},
+}
+
+// MigrationMismatch is a migration version that is recorded with different
+// names in MySQL and Postgres.
+type MigrationMismatch struct {
+	Version  int
+	MySQL    string
+	Postgres string
+}
+
+// MigrationParity is the result of comparing the applied migrations of the
+// MySQL and Postgres databases.
+type MigrationParity struct {
+	// MissingInPostgres are applied to MySQL but are pending on Postgres.
+	MissingInPostgres []Migration
+	// MissingInMySQL are applied to Postgres but are pending on MySQL.
+	MissingInMySQL []Migration
+	NameMismatches []MigrationMismatch
+}
+
+// InParity reports whether both databases are at the same migration.
+func (p *MigrationParity) InParity() bool {
+	return len(p.MissingInPostgres) == 0 && len(p.MissingInMySQL) == 0 && len(p.NameMismatches) == 0
+}
+
+// GetMigrations returns the applied migrations ordered by their versions.
+func (db *DB) GetMigrations(ctx context.Context) ([]Migration, error) {
+	var query string
+	switch db.dbType {
+	case "mysql":
+		query = "SELECT Version, Name FROM db_migrations ORDER BY Version"
+	case "postgres":
+		query = "SELECT version, name FROM db_migrations ORDER BY version"
+	default:
+		return nil, fmt.Errorf("unsupported db type: %s", db.dbType)
+	}
+
+	rows, err := db.queryContext(ctx, db.conn, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not query migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var migrations []Migration
+	for rows.Next() {
+		var m Migration
+		if err := rows.Scan(&m.Version, &m.Name); err != nil {
+			return nil, fmt.Errorf("could not scan migration: %w", err)
+		}
+		migrations = append(migrations, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during query: %w", err)
+	}
+
+	return migrations, nil
+}
+
+// CompareMigrations compares the applied migrations of MySQL and Postgres by
+// their versions and names, the known renames are considered equal.
+func CompareMigrations(mysqlMigrations, postgresMigrations []Migration) *MigrationParity {
+	parity := &MigrationParity{}
+
+	applied := make(map[int]string, len(postgresMigrations))
+	for _, m := range postgresMigrations {
+		applied[m.Version] = m.Name
+	}
+
+	seen := make(map[int]bool, len(mysqlMigrations))
+	for _, m := range mysqlMigrations {
+		seen[m.Version] = true
+
+		name, ok := applied[m.Version]
+		if !ok {
+			parity.MissingInPostgres = append(parity.MissingInPostgres, m)
+			continue
+		}
+
+		if name == m.Name {
+			continue
+		}
+
+		if rename, ok := migrationRenames[m.Version]; ok && rename[0] == m.Name && rename[1] == name {
+			continue
+		}
+
+		parity.NameMismatches = append(parity.NameMismatches, MigrationMismatch{
+			Version:  m.Version,
+			MySQL:    m.Name,
+			Postgres: name,
+		})
+	}
+
+	for _, m := range postgresMigrations {
+		if !seen[m.Version] {
+			parity.MissingInMySQL = append(parity.MissingInMySQL, m)
+		}
+	}
+
+	return parity
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		mysql    []Migration
+		postgres []Migration
+		expected MigrationParity
+	}{
+		{
+			name:     "in parity",
+			mysql:    []Migration{{1, "create_teams"}, {2, "create_users"}},
+			postgres: []Migration{{1, "create_teams"}, {2, "create_users"}},
+		},
+		{
+			name:     "known rename",
+			mysql:    []Migration{{92, "add_createat_to_teamembers"}},
+			postgres: []Migration{{92, "add_createat_to_teammembers"}},
+		},
+		{
+			name:     "reversed rename",
+			mysql:    []Migration{{92, "add_createat_to_teammembers"}},
+			postgres: []Migration{{92, "add_createat_to_teamembers"}},
+			expected: MigrationParity{
+				NameMismatches: []MigrationMismatch{{Version: 92, MySQL: "add_createat_to_teammembers", Postgres: "add_createat_to_teamembers"}},
+			},
+		},
+		{
+			name:     "missing in postgres",
+			mysql:    []Migration{{1, "create_teams"}, {2, "create_users"}},
+			postgres: []Migration{{1, "create_teams"}},
+			expected: MigrationParity{
+				MissingInPostgres: []Migration{{2, "create_users"}},
+			},
+		},
+		{
+			name:     "missing in mysql",
+			mysql:    []Migration{{1, "create_teams"}},
+			postgres: []Migration{{1, "create_teams"}, {2, "create_users"}},
+			expected: MigrationParity{
+				MissingInMySQL: []Migration{{2, "create_users"}},
+			},
+		},
+		{
+			name:     "name mismatch",
+			mysql:    []Migration{{1, "create_teams"}},
+			postgres: []Migration{{1, "create_channels"}},
+			expected: MigrationParity{
+				NameMismatches: []MigrationMismatch{{Version: 1, MySQL: "create_teams", Postgres: "create_channels"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parity := CompareMigrations(tc.mysql, tc.postgres)
+			if !reflect.DeepEqual(*parity, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, *parity)
+			}
+
+			inParity := len(tc.expected.MissingInPostgres) == 0 && len(tc.expected.MissingInMySQL) == 0 && len(tc.expected.NameMismatches) == 0
+			if parity.InParity() != inParity {
+				t.Errorf("expected in parity to be %t", inParity)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build embedmigrations
+
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed mysql postgres
+var assets embed.FS
+
+func Assets() (fs.FS, bool) {
+	return assets, true
+}
@@ -0,0 +1,11 @@
+//go:build !embedmigrations
+
+package migrations
+
+import "io/fs"
+
+// Assets returns false as the binary is built without the migrations, the
+// embedmigrations build tag should be set to include them.
+func Assets() (fs.FS, bool) {
+	return nil, false
+}
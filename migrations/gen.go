@@ -0,0 +1,43 @@
+//go:build ignore
+
+// This program fetches the migrations of the supported versions so that they
+// can be embedded into the binary with the embedmigrations build tag.
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/isacikgoz/migration-assist/internal/git"
+	"github.com/isacikgoz/migration-assist/internal/logger"
+	"github.com/isacikgoz/migration-assist/migrations"
+)
+
+func main() {
+	baseLogger, err := logger.NewLogger(os.Stderr, logger.Options{})
+	if err != nil {
+		log.Fatalf("could not create logger: %s", err)
+	}
+
+	for _, version := range migrations.SupportedVersions {
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			log.Fatalf("could not parse version: %s", err)
+		}
+
+		for _, driver := range []string{"mysql", "postgres"} {
+			baseLogger.Info("fetching migrations", "driver", driver, "version", version)
+			err = git.CloneMigrations(git.CloneOptions{
+				Output:     filepath.Join(driver, version),
+				DriverType: driver,
+				Version:    v,
+			}, baseLogger)
+			if err != nil {
+				log.Fatalf("could not fetch migrations: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package migrations embeds the Mattermost migrations of the supported
+// versions when the binary is built with the embedmigrations build tag.
+// The migrations should be fetched with go generate beforehand.
+package migrations
+
+//go:generate go run gen.go
+
+import (
+	"io/fs"
+
+	"github.com/blang/semver/v4"
+)
+
+// SupportedVersions are the Mattermost versions whose migrations are embedded.
+var SupportedVersions = []string{
+	"v8.1.0",
+	"v9.0.0",
+	"v9.1.0",
+	"v9.2.0",
+	"v9.3.0",
+	"v9.4.0",
+	"v9.5.0",
+	"v9.6.0",
+	"v9.7.0",
+}
+
+// FS returns the embedded migrations of the driver for the given version
+// along with the version that they are embedded for. The patch releases don't
+// add migrations, hence the embedded version with the same major and minor
+// version is used.
+func FS(driver string, version semver.Version) (fs.FS, semver.Version, bool) {
+	assets, ok := Assets()
+	if !ok {
+		return nil, semver.Version{}, false
+	}
+
+	embedded, ok := embeddedVersion(version)
+	if !ok {
+		return nil, semver.Version{}, false
+	}
+
+	dir := driver + "/v" + embedded.String()
+	if _, err := fs.Stat(assets, dir); err != nil {
+		return nil, semver.Version{}, false
+	}
+
+	sub, err := fs.Sub(assets, dir)
+	if err != nil {
+		return nil, semver.Version{}, false
+	}
+
+	return sub, embedded, true
+}
+
+// embeddedVersion returns the supported version with the same major and minor
+// version. The closest patch release that is not newer than the version is
+// preferred, otherwise the closest newer one is used.
+func embeddedVersion(version semver.Version) (semver.Version, bool) {
+	var older, newer *semver.Version
+	for _, s := range SupportedVersions {
+		v, err := semver.ParseTolerant(s)
+		if err != nil || v.Major != version.Major || v.Minor != version.Minor {
+			continue
+		}
+
+		if v.Patch <= version.Patch {
+			if older == nil || v.Patch > older.Patch {
+				older = &v
+			}
+		} else if newer == nil || v.Patch < newer.Patch {
+			newer = &v
+		}
+	}
+
+	switch {
+	case older != nil:
+		return *older, true
+	case newer != nil:
+		return *newer, true
+	default:
+		return semver.Version{}, false
+	}
+}
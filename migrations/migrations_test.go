@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+)
+
+func TestEmbeddedVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		embedded string
+	}{
+		{version: "9.7.0", embedded: "9.7.0"},
+		{version: "9.7.3", embedded: "9.7.0"},
+		{version: "8.1.12", embedded: "8.1.0"},
+		{version: "9.7.0-rc1", embedded: "9.7.0"},
+		{version: "9.8.0"},
+		{version: "7.8.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			embedded, ok := embeddedVersion(semver.MustParse(tc.version))
+			if ok != (tc.embedded != "") {
+				t.Fatalf("expected ok to be %t, got %t", tc.embedded != "", ok)
+			}
+			if ok && embedded.String() != tc.embedded {
+				t.Errorf("expected %s, got %s", tc.embedded, embedded)
+			}
+		})
+	}
+}
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM ChannelMembers WHERE NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = ChannelMembers.ChannelId);
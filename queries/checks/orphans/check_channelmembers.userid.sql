@@ -0,0 +1 @@
+SELECT COUNT(*) FROM ChannelMembers WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = ChannelMembers.UserId);
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Channels WHERE Channels.TeamId <> '' AND NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = Channels.TeamId);
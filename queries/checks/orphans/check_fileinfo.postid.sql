@@ -0,0 +1 @@
+SELECT COUNT(*) FROM FileInfo WHERE FileInfo.PostId <> '' AND NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = FileInfo.PostId);
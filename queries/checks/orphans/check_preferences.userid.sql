@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Preferences WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Preferences.UserId);
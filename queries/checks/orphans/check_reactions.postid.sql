@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Reactions WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Reactions.PostId);
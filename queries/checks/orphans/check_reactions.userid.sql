@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Reactions WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Reactions.UserId);
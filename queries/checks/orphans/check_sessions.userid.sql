@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Sessions WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Sessions.UserId);
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM TeamMembers WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = TeamMembers.UserId);
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM ThreadMemberships WHERE NOT EXISTS (SELECT 1 FROM Threads WHERE Threads.PostId = ThreadMemberships.PostId);
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Threads WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Threads.PostId);
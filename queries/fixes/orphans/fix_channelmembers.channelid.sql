@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_channelmembers LIKE ChannelMembers;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_channelmembers SELECT * FROM ChannelMembers WHERE NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = ChannelMembers.ChannelId);
+
+DELETE ChannelMembers FROM ChannelMembers JOIN orphaned_channelmembers ON orphaned_channelmembers.ChannelId = ChannelMembers.ChannelId AND orphaned_channelmembers.UserId = ChannelMembers.UserId WHERE NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = ChannelMembers.ChannelId);
+
+COMMIT;
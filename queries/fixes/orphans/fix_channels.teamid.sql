@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_channels LIKE Channels;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_channels SELECT * FROM Channels WHERE Channels.TeamId <> '' AND NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = Channels.TeamId);
+
+DELETE Channels FROM Channels JOIN orphaned_channels ON orphaned_channels.Id = Channels.Id WHERE Channels.TeamId <> '' AND NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = Channels.TeamId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_fileinfo LIKE FileInfo;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_fileinfo SELECT * FROM FileInfo WHERE FileInfo.PostId <> '' AND NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = FileInfo.PostId);
+
+DELETE FileInfo FROM FileInfo JOIN orphaned_fileinfo ON orphaned_fileinfo.Id = FileInfo.Id WHERE FileInfo.PostId <> '' AND NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = FileInfo.PostId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_posts LIKE Posts;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_posts SELECT * FROM Posts WHERE NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = Posts.ChannelId);
+
+DELETE Posts FROM Posts JOIN orphaned_posts ON orphaned_posts.Id = Posts.Id WHERE NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = Posts.ChannelId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_preferences LIKE Preferences;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_preferences SELECT * FROM Preferences WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Preferences.UserId);
+
+DELETE Preferences FROM Preferences JOIN orphaned_preferences ON orphaned_preferences.UserId = Preferences.UserId AND orphaned_preferences.Category = Preferences.Category AND orphaned_preferences.Name = Preferences.Name WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Preferences.UserId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_reactions LIKE Reactions;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_reactions SELECT * FROM Reactions WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Reactions.PostId);
+
+DELETE Reactions FROM Reactions JOIN orphaned_reactions ON orphaned_reactions.PostId = Reactions.PostId AND orphaned_reactions.UserId = Reactions.UserId AND orphaned_reactions.EmojiName = Reactions.EmojiName WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Reactions.PostId);
+
+COMMIT;
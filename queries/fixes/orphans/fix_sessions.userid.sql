@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_sessions LIKE Sessions;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_sessions SELECT * FROM Sessions WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Sessions.UserId);
+
+DELETE Sessions FROM Sessions JOIN orphaned_sessions ON orphaned_sessions.Id = Sessions.Id WHERE NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = Sessions.UserId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_teammembers LIKE TeamMembers;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_teammembers SELECT * FROM TeamMembers WHERE NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = TeamMembers.TeamId);
+
+DELETE TeamMembers FROM TeamMembers JOIN orphaned_teammembers ON orphaned_teammembers.TeamId = TeamMembers.TeamId AND orphaned_teammembers.UserId = TeamMembers.UserId WHERE NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = TeamMembers.TeamId);
+
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_threadmemberships LIKE ThreadMemberships;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_threadmemberships SELECT * FROM ThreadMemberships WHERE NOT EXISTS (SELECT 1 FROM Threads WHERE Threads.PostId = ThreadMemberships.PostId);
+
+DELETE ThreadMemberships FROM ThreadMemberships JOIN orphaned_threadmemberships ON orphaned_threadmemberships.PostId = ThreadMemberships.PostId AND orphaned_threadmemberships.UserId = ThreadMemberships.UserId WHERE NOT EXISTS (SELECT 1 FROM Threads WHERE Threads.PostId = ThreadMemberships.PostId);
+
+COMMIT;
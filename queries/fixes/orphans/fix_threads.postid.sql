@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS orphaned_threads LIKE Threads;
+
+START TRANSACTION;
+
+REPLACE INTO orphaned_threads SELECT * FROM Threads WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Threads.PostId);
+
+DELETE Threads FROM Threads JOIN orphaned_threads ON orphaned_threads.PostId = Threads.PostId WHERE NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.Id = Threads.PostId);
+
+COMMIT;
@@ -1 +1 @@
-CREATE INDEX IF NOT EXISTS idx_fileinfo_content_txt ON fileinfo USING gin(to_tsvector('english', content));
+CREATE INDEX {{ if .Concurrently }}CONCURRENTLY {{ end }}IF NOT EXISTS idx_fileinfo_content_txt ON {{ .Schema }}.fileinfo USING gin(to_tsvector('{{ .TextSearchConfig }}', content));
@@ -1 +1 @@
-CREATE INDEX IF NOT EXISTS idx_posts_message_txt ON posts USING gin(to_tsvector('english', message));
+CREATE INDEX {{ if .Concurrently }}CONCURRENTLY {{ end }}IF NOT EXISTS idx_posts_message_txt ON {{ .Schema }}.posts USING gin(to_tsvector('{{ .TextSearchConfig }}', message));